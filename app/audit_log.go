@@ -0,0 +1,38 @@
+package app
+
+import "time"
+
+type GetAllAuditLogRequest struct {
+	ActorID    int       `form:"actorId"`
+	Action     string    `form:"action"`
+	TargetType string    `form:"targetType"`
+	TargetID   string    `form:"targetId"`
+	From       time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To         time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit      int       `form:"limit"`
+	Offset     int       `form:"offset"`
+}
+
+type GetAllAuditLogResponse struct {
+	AuditLogs []AuditLogs `json:"auditLogs"`
+	Total     int64       `json:"total"`
+}
+
+type AuditLogs struct {
+	ID         int                    `json:"id"`
+	ActorID    int                    `json:"actorId"`
+	Actor      string                 `json:"actor,omitempty"`
+	Action     string                 `json:"action"`
+	TargetType string                 `json:"targetType"`
+	TargetID   string                 `json:"targetId"`
+	IP         string                 `json:"ip"`
+	UserAgent  string                 `json:"userAgent"`
+	RequestID  string                 `json:"requestId"`
+	Changes    map[string]AuditChange `json:"changes,omitempty"`
+	CreatedAt  time.Time              `json:"createdAt"`
+}
+
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
@@ -0,0 +1,20 @@
+package app
+
+import "time"
+
+type ConfigStatusResponse struct {
+	Version    int                    `json:"version"`
+	LoadedAt   time.Time              `json:"loadedAt"`
+	Reloadable map[string]interface{} `json:"reloadable"`
+	History    []ConfigReload         `json:"history"`
+}
+
+type ConfigReload struct {
+	Version         int       `json:"version"`
+	Source          string    `json:"source"`
+	Status          string    `json:"status"`
+	Changed         []string  `json:"changed,omitempty"`
+	RestartRequired bool      `json:"restartRequired,omitempty"`
+	Error           string    `json:"error,omitempty"`
+	At              time.Time `json:"at"`
+}
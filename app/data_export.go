@@ -0,0 +1,57 @@
+package app
+
+import "time"
+
+type DataExportResponse struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"`
+	DownloadURL string     `json:"downloadUrl,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	ErrorCode   string     `json:"errorCode,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+type GetDataExportByIdRequest struct {
+	ID string `uri:"exportId" binding:"required"`
+}
+
+type DownloadDataExportRequest struct {
+	Token string `uri:"token" binding:"required"`
+}
+
+type DataExportManifest struct {
+	GeneratedAt   time.Time         `json:"generatedAt"`
+	Profile       DataExportProfile `json:"profile"`
+	Photos        []DataExportPhoto `json:"photos"`
+	Sessions      []Sessions        `json:"sessions"`
+	Notifications []Notifications   `json:"notifications"`
+	AuditLogs     []AuditLogs       `json:"auditLogs"`
+	Files         []DataExportFile  `json:"files"`
+}
+
+type DataExportProfile struct {
+	ID        int       `json:"id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type DataExportPhoto struct {
+	ID          int        `json:"id"`
+	Title       string     `json:"title"`
+	Caption     string     `json:"caption"`
+	Tags        string     `json:"tags,omitempty"`
+	Album       string     `json:"album,omitempty"`
+	Status      string     `json:"status"`
+	PhotoURL    string     `json:"photoUrl"`
+	OriginalURL string     `json:"originalUrl,omitempty"`
+	File        string     `json:"file,omitempty"`
+	DeletedAt   *time.Time `json:"deletedAt,omitempty"`
+}
+
+type DataExportFile struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
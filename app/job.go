@@ -0,0 +1,34 @@
+package app
+
+import "time"
+
+type GetAllJobRequest struct {
+	Type   string `form:"type"`
+	Status string `form:"status"`
+	Limit  int    `form:"limit"`
+	Offset int    `form:"offset"`
+}
+
+type JobByIdRequest struct {
+	ID int `uri:"jobId" binding:"required"`
+}
+
+type GetAllJobResponse struct {
+	Jobs   []Jobs           `json:"jobs"`
+	Total  int64            `json:"total"`
+	Counts map[string]int64 `json:"counts"`
+}
+
+type Jobs struct {
+	ID          int        `json:"id"`
+	Type        string     `json:"type"`
+	Payload     string     `json:"payload"`
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"maxAttempts"`
+	LastError   string     `json:"lastError,omitempty"`
+	RunAt       time.Time  `json:"runAt"`
+	LockedAt    *time.Time `json:"lockedAt,omitempty"`
+	LockedBy    string     `json:"lockedBy,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
@@ -0,0 +1,15 @@
+package app
+
+import "time"
+
+type GetAllNotificationResponse struct {
+	Notifications []Notifications `json:"notifications"`
+}
+
+type Notifications struct {
+	ID        int        `json:"id"`
+	Title     string     `json:"title"`
+	Message   string     `json:"message"`
+	ReadAt    *time.Time `json:"readAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
@@ -1,11 +1,16 @@
 package app
 
-import "mime/multipart"
+import (
+	"mime/multipart"
+	"time"
+)
 
 type PhotoRequest struct {
 	Photo   *multipart.FileHeader `form:"file" binding:"required"`
-	Title   string                `form:"title" binding:"required"`
-	Caption string                `form:"caption" binding:"required"`
+	Title   string                `form:"title" binding:"required,title=100" normalize:"trim,squash"`
+	Caption string                `form:"caption" binding:"required" normalize:"trim"`
+	Tags    string                `form:"tags" normalize:"trim"`
+	Album   string                `form:"album" normalize:"trim"`
 }
 
 type GetAllPhotoByIdResponse struct {
@@ -13,19 +18,58 @@ type GetAllPhotoByIdResponse struct {
 }
 
 type Photos struct {
-	ID       int
-	Title    string
-	Caption  string
-	PhotoURL string
-	UserID   int
+	ID          int
+	Title       string
+	Caption     string
+	PhotoURL    string
+	OriginalURL string
+	Tags        string
+	Album       string
+	Status      string
+	UserID      int
 }
 
 type UpdatePhotoByIdRequest struct {
 	Photo   *multipart.FileHeader `form:"file" binding:"required"`
-	Title   string                `form:"title" binding:"required"`
-	Caption string                `form:"caption" binding:"required"`
+	Title   string                `form:"title" binding:"required,title=100" normalize:"trim,squash"`
+	Caption string                `form:"caption" binding:"required" normalize:"trim"`
+	Tags    string                `form:"tags" normalize:"trim"`
+	Album   string                `form:"album" normalize:"trim"`
 }
 
 type DeletePhotoByIdRequest struct {
 	ID int `uri:"photoId" binding:"required"`
 }
+
+type GetAllTrashPhotoResponse struct {
+	Photos []TrashPhotos `json:"photos"`
+}
+
+type TrashPhotos struct {
+	ID        int
+	Title     string
+	Caption   string
+	PhotoURL  string
+	UserID    int
+	DeletedAt time.Time
+	PurgeAt   time.Time
+}
+
+type RestorePhotoByIdRequest struct {
+	ID int `uri:"photoId" binding:"required"`
+}
+
+type TransformRequest struct {
+	Width     int    `form:"w" binding:"min=0"`
+	Height    int    `form:"h" binding:"min=0"`
+	Fit       string `form:"fit" binding:"omitempty,oneof=contain cover fill"`
+	Quality   int    `form:"q" binding:"min=0,max=100"`
+	Format    string `form:"fmt" binding:"omitempty,oneof=jpeg png webp"`
+	Expires   int64  `form:"exp"`
+	Signature string `form:"s"`
+}
+
+type TransformURLResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"mime/multipart"
+	"time"
+)
+
+type PhotoImportRequest struct {
+	Archive *multipart.FileHeader `form:"file" binding:"required"`
+}
+
+type GetPhotoImportByIdRequest struct {
+	ID string `uri:"importId" binding:"required"`
+}
+
+type PhotoImportResponse struct {
+	ID          string              `json:"id"`
+	Status      string              `json:"status"`
+	Total       int                 `json:"total"`
+	Processed   int                 `json:"processed"`
+	Imported    int                 `json:"imported"`
+	Skipped     int                 `json:"skipped"`
+	Rejected    int                 `json:"rejected"`
+	Results     []PhotoImportResult `json:"results,omitempty"`
+	ErrorCode   string              `json:"errorCode,omitempty"`
+	Error       string              `json:"error,omitempty"`
+	CompletedAt *time.Time          `json:"completedAt,omitempty"`
+	CreatedAt   time.Time           `json:"createdAt"`
+}
+
+type PhotoImportResult struct {
+	File    string `json:"file"`
+	Status  string `json:"status"`
+	Code    string `json:"code,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+	PhotoID int    `json:"photoId,omitempty"`
+}
+
+type PhotoImportMetadata struct {
+	File    string `json:"file"`
+	Title   string `json:"title" binding:"required,title=100" normalize:"trim,squash"`
+	Caption string `json:"caption" binding:"max=2000" normalize:"trim"`
+	Tags    string `json:"tags" binding:"max=255" normalize:"trim"`
+	Album   string `json:"album" binding:"omitempty,title=100" normalize:"trim,squash"`
+}
@@ -0,0 +1,20 @@
+package app
+
+import "time"
+
+type GetAllSessionResponse struct {
+	Sessions []Sessions `json:"sessions"`
+}
+
+type Sessions struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	Current    bool      `json:"current"`
+}
+
+type RevokeSessionByIdRequest struct {
+	ID string `uri:"id" binding:"required"`
+}
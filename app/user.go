@@ -3,8 +3,8 @@ package app
 import "time"
 
 type LoginRequest struct {
-	Email    string `json:"email" binding:"required" validate:"email"`
-	Password string `json:"password" binding:"required" validate:"min:6"`
+	Email    string `json:"email" binding:"required,email" normalize:"trim,lower"`
+	Password string `json:"password" binding:"required"`
 }
 
 type LoginResponse struct {
@@ -12,19 +12,37 @@ type LoginResponse struct {
 }
 
 type RegisterRequest struct {
-	Username string `json:"username" binding:"required" validate:"min:1"`
-	Email    string `json:"email" binding:"required" validate:"email"`
-	Password string `json:"password" binding:"required" validate:"min:6"`
+	Username string `json:"username" binding:"required,username" normalize:"trim"`
+	Email    string `json:"email" binding:"required,email,unique_email" normalize:"trim,lower"`
+	Password string `json:"password" binding:"required,min=6"`
 }
 
 type GetUserByIdResponse struct {
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"createdAt"`
+	Username  string       `json:"username"`
+	Email     string       `json:"email"`
+	Locale    string       `json:"locale"`
+	Storage   StorageUsage `json:"storage"`
+	CreatedAt time.Time    `json:"createdAt"`
+}
+
+type StorageUsage struct {
+	Plan         string `json:"plan"`
+	UsedBytes    int64  `json:"usedBytes"`
+	MaxBytes     int64  `json:"maxBytes"`
+	Photos       int64  `json:"photos"`
+	MaxPhotos    int64  `json:"maxPhotos"`
+	MaxFileBytes int64  `json:"maxFileBytes"`
 }
 
 type UpdateUserByIdRequest struct {
-	Username string `json:"username,omitempty"`
-	Email    string `json:"email,omitempty"`
-	Password string `json:"password,omitempty"`
+	Username string `json:"username,omitempty" binding:"omitempty,username" normalize:"trim"`
+	Email    string `json:"email,omitempty" binding:"omitempty,email" normalize:"trim,lower"`
+	Password string `json:"password,omitempty" binding:"omitempty,min=6"`
+	Locale   string `json:"locale,omitempty" binding:"omitempty,locale" normalize:"trim,lower"`
+}
+
+type UserEvent struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
 }
@@ -0,0 +1,53 @@
+package app
+
+import "time"
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required,url" normalize:"trim"`
+	Events []string `json:"events" binding:"required,min=1" normalize:"trim"`
+	Secret string   `json:"secret"`
+}
+
+type WebhookByIdRequest struct {
+	ID int `uri:"webhookId" binding:"required"`
+}
+
+type WebhookDeliveryByIdRequest struct {
+	WebhookID int `uri:"webhookId" binding:"required"`
+	ID        int `uri:"deliveryId" binding:"required"`
+}
+
+type CreateWebhookResponse struct {
+	ID     int    `json:"id"`
+	Secret string `json:"secret"`
+}
+
+type GetAllWebhookResponse struct {
+	Webhooks []Webhooks `json:"webhooks"`
+}
+
+type Webhooks struct {
+	ID        int       `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Global    bool      `json:"global"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type GetAllWebhookDeliveryResponse struct {
+	Deliveries []WebhookDeliveries `json:"deliveries"`
+}
+
+type WebhookDeliveries struct {
+	ID           int       `json:"id"`
+	EventID      string    `json:"eventId"`
+	EventType    string    `json:"eventType"`
+	Attempt      int       `json:"attempt"`
+	StatusCode   int       `json:"statusCode"`
+	ResponseBody string    `json:"responseBody,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	DurationMs   int64     `json:"durationMs"`
+	Success      bool      `json:"success"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
@@ -0,0 +1,125 @@
+package apperror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+type Error struct {
+	Code    string
+	Status  int
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// registered holds every sentinel declared through New so the API docs can
+// list the codes a client may receive.
+var registered []*Error
+
+func New(status int, code, message string) *Error {
+	err := &Error{
+		Code:    code,
+		Status:  status,
+		Message: message,
+	}
+	registered = append(registered, err)
+
+	return err
+}
+
+// Codes returns the code of every declared sentinel, sorted.
+func Codes() []string {
+	codes := make([]string, 0, len(registered))
+	for _, err := range registered {
+		codes = append(codes, err.Code)
+	}
+	sort.Strings(codes)
+
+	return codes
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Code, e.Err)
+	}
+
+	return e.Code + ": " + e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	other, ok := target.(*Error)
+	return ok && other.Code == e.Code
+}
+
+func (e *Error) Wrap(err error) *Error {
+	wrapped := *e
+	wrapped.Err = err
+
+	return &wrapped
+}
+
+func (e *Error) WithFields(fields []FieldError) *Error {
+	wrapped := *e
+	wrapped.Fields = fields
+
+	return &wrapped
+}
+
+func From(err error) *Error {
+	var (
+		appErr        *Error
+		validationErr validator.ValidationErrors
+		mysqlErr      *mysql.MySQLError
+		syntaxErr     *json.SyntaxError
+		typeErr       *json.UnmarshalTypeError
+	)
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &appErr):
+		return appErr
+	case errors.As(err, &validationErr):
+		return ErrValidation.Wrap(err)
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return ErrMalformedRequest.Wrap(err)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound.Wrap(err)
+	case errors.As(err, &mysqlErr) && mysqlErr.Number == 1062:
+		return ErrConflict.Wrap(err)
+	}
+
+	return ErrInternal.Wrap(err)
+}
+
+func FromBinding(err error) *Error {
+	var validationErr validator.ValidationErrors
+	if errors.As(err, &validationErr) {
+		return From(err)
+	}
+	// http.MaxBytesError only exists from go 1.19
+	if strings.Contains(err.Error(), "request body too large") {
+		return ErrRequestTooLarge.Wrap(err)
+	}
+
+	return ErrMalformedRequest.Wrap(err)
+}
@@ -0,0 +1,57 @@
+package apperror
+
+import "net/http"
+
+var (
+	ErrInternal         = New(http.StatusInternalServerError, "INTERNAL_ERROR", "something went wrong")
+	ErrValidation       = New(http.StatusBadRequest, "VALIDATION_FAILED", "request validation failed")
+	ErrMalformedRequest = New(http.StatusBadRequest, "MALFORMED_REQUEST", "request body is malformed")
+	ErrNotFound         = New(http.StatusNotFound, "NOT_FOUND", "resource not found")
+	ErrConflict         = New(http.StatusConflict, "CONFLICT", "resource already exists")
+	ErrNotReady         = New(http.StatusServiceUnavailable, "NOT_READY", "service is not ready")
+	ErrRateLimited      = New(http.StatusTooManyRequests, "RATE_LIMITED", "too many requests, try again later")
+
+	ErrTokenMissing       = New(http.StatusUnauthorized, "TOKEN_MISSING", "token not found")
+	ErrTokenInvalid       = New(http.StatusUnauthorized, "TOKEN_INVALID", "invalid token")
+	ErrSessionRevoked     = New(http.StatusUnauthorized, "SESSION_REVOKED", "invalid session")
+	ErrInvalidCredentials = New(http.StatusUnauthorized, "INVALID_CREDENTIALS", "wrong email or password")
+	ErrForbidden          = New(http.StatusForbidden, "FORBIDDEN", "access denied")
+	ErrAccountDisabled    = New(http.StatusForbidden, "ACCOUNT_DISABLED", "account is disabled")
+
+	ErrUserNotFound = New(http.StatusNotFound, "USER_NOT_FOUND", "user not found")
+	ErrEmailTaken   = New(http.StatusConflict, "EMAIL_TAKEN", "duplicate email")
+
+	ErrSessionNotFound = New(http.StatusNotFound, "SESSION_NOT_FOUND", "session not found")
+
+	ErrPhotoNotFound       = New(http.StatusNotFound, "PHOTO_NOT_FOUND", "photo not found")
+	ErrPhotoNotInTrash     = New(http.StatusNotFound, "PHOTO_NOT_IN_TRASH", "photo not found in trash")
+	ErrInvalidPhotoId      = New(http.StatusBadRequest, "INVALID_PHOTO_ID", "invalid photo id")
+	ErrInvalidFile         = New(http.StatusBadRequest, "INVALID_FILE", "invalid file")
+	ErrInvalidFileType     = New(http.StatusUnsupportedMediaType, "INVALID_FILE_TYPE", "invalid file type")
+	ErrFileUnreadable      = New(http.StatusInternalServerError, "FILE_UNREADABLE", "can't open file")
+	ErrFileNotSaved        = New(http.StatusInternalServerError, "FILE_NOT_SAVED", "can't save file")
+	ErrFileTooLarge        = New(http.StatusRequestEntityTooLarge, "FILE_TOO_LARGE", "file exceeds the maximum allowed size")
+	ErrImageTooLarge       = New(http.StatusUnprocessableEntity, "IMAGE_TOO_LARGE", "image dimensions exceed the allowed limit")
+	ErrRequestTooLarge     = New(http.StatusRequestEntityTooLarge, "REQUEST_TOO_LARGE", "request body is too large")
+	ErrInvalidTransform    = New(http.StatusBadRequest, "INVALID_TRANSFORM", "invalid transform parameters")
+	ErrInvalidSignature    = New(http.StatusForbidden, "INVALID_SIGNATURE", "invalid or missing signature")
+	ErrSignatureExpired    = New(http.StatusGone, "SIGNATURE_EXPIRED", "signed link has expired")
+	ErrCannotTransform     = New(http.StatusUnprocessableEntity, "CANNOT_TRANSFORM", "photo format can't be transformed")
+	ErrStorageQuota        = New(http.StatusForbidden, "QUOTA_EXCEEDED", "storage quota exceeded")
+	ErrPhotoLimit          = New(http.StatusForbidden, "PHOTO_LIMIT_REACHED", "photo limit reached")
+	ErrInvalidArchive      = New(http.StatusBadRequest, "INVALID_ARCHIVE", "invalid zip archive")
+	ErrImportNotFound      = New(http.StatusNotFound, "IMPORT_NOT_FOUND", "import not found")
+	ErrImportInterrupted   = New(http.StatusInternalServerError, "IMPORT_INTERRUPTED", "import was interrupted")
+	ErrInvalidSidecar      = New(http.StatusBadRequest, "INVALID_SIDECAR", "metadata file is invalid")
+	ErrInvalidMetadata     = New(http.StatusBadRequest, "INVALID_METADATA", "photo metadata is invalid")
+	ErrTooManyFiles        = New(http.StatusBadRequest, "TOO_MANY_FILES", "archive contains too many files")
+	ErrSystemFile          = New(http.StatusBadRequest, "SYSTEM_FILE", "system file")
+	ErrExportNotFound      = New(http.StatusNotFound, "EXPORT_NOT_FOUND", "export not found")
+	ErrExportLinkExpired   = New(http.StatusGone, "EXPORT_LINK_EXPIRED", "download link is invalid or expired")
+	ErrJobNotFound         = New(http.StatusNotFound, "JOB_NOT_FOUND", "job not found")
+	ErrJobNotRetryable     = New(http.StatusConflict, "JOB_NOT_RETRYABLE", "job not found or not retryable")
+	ErrWebhookNotFound     = New(http.StatusNotFound, "WEBHOOK_NOT_FOUND", "webhook not found")
+	ErrWebhookInvalidURL   = New(http.StatusBadRequest, "WEBHOOK_INVALID_URL", "webhook url must use http or https")
+	ErrWebhookInvalidEvent = New(http.StatusBadRequest, "WEBHOOK_INVALID_EVENT", "unknown event type")
+	ErrDeliveryNotFound    = New(http.StatusNotFound, "DELIVERY_NOT_FOUND", "delivery not found")
+)
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"rakamin/helpers"
+	"rakamin/models"
+	"strings"
+	"time"
+)
+
+func runStorage(args []string) error {
+	if len(args) == 0 || args[0] != "gc" {
+		return fmt.Errorf("usage: rakamin storage gc [--dry-run] [--min-age 1h]")
+	}
+
+	flags := newFlagSet("storage gc")
+	dryRun := flags.Bool("dry-run", false, "list orphaned files without removing them")
+	minAge := flags.Duration("min-age", time.Hour, "skip files younger than this to spare uploads in flight")
+	flags.Parse(args[1:])
+
+	_, db, err := setupCommand()
+	if err != nil {
+		return err
+	}
+
+	urls, err := models.NewPhotoRepository(db).GetAllPhotoURLs()
+	if err != nil {
+		return err
+	}
+	referenced := map[string]bool{}
+	for _, url := range urls {
+		referenced[imageBaseName(url)] = true
+	}
+
+	var removed, freed int64
+	for _, dir := range []string{helpers.ImageDir, helpers.VariantDir} {
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			// Other dotfiles aren't ours, but temp files of uploads that never
+			// finished are, and minAge keeps the ones in flight.
+			if entry.IsDir() || (strings.HasPrefix(entry.Name(), ".") && !strings.HasPrefix(entry.Name(), helpers.UploadTempPrefix)) {
+				continue
+			}
+
+			// Variants share the base name of their photo's file.
+			path := filepath.Join(dir, entry.Name())
+			if referenced[imageBaseName(path)] {
+				continue
+			}
+
+			info, err := entry.Info()
+			if err != nil || time.Since(info.ModTime()) < *minAge {
+				continue
+			}
+
+			if *dryRun {
+				fmt.Printf("would remove %s (%d bytes)\n", path, info.Size())
+			} else {
+				err = os.Remove(path)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("removed %s (%d bytes)\n", path, info.Size())
+			}
+			removed++
+			freed += info.Size()
+		}
+	}
+
+	fmt.Printf("%d orphaned files, %d bytes\n", removed, freed)
+
+	return nil
+}
+
+func imageBaseName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
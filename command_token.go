@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"rakamin/app"
+	"rakamin/apperror"
+	"rakamin/helpers"
+	"rakamin/middlewares"
+	"rakamin/models"
+)
+
+func runToken(args []string) error {
+	if len(args) == 0 || args[0] != "issue" {
+		return fmt.Errorf("usage: rakamin token issue [--expiry 1h] <id|email>")
+	}
+
+	flags := newFlagSet("token issue")
+	expiry := flags.Duration("expiry", 0, "token lifetime, defaults to jwt.expiry")
+	flags.Parse(args[1:])
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: rakamin token issue [--expiry 1h] <id|email>")
+	}
+
+	configApp, db, err := setupCommand()
+	if err != nil {
+		return err
+	}
+	if *expiry <= 0 {
+		*expiry = configApp.JWT.Expiry
+	}
+
+	userRepo := models.NewUserRepository(db)
+	sessionRepo := models.NewSessionRepository(db)
+	authMiddleware := middlewares.NewAuthorizationMiddleware(configApp.JWT.Secret, *expiry, sessionRepo)
+
+	user, err := findUser(userRepo, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if user.DisabledAt != nil {
+		return apperror.ErrAccountDisabled
+	}
+
+	session := models.Session{
+		ID:        helpers.GetUUID(),
+		UserID:    user.ID,
+		UserAgent: cliUserAgent,
+	}
+	err = sessionRepo.Create(session)
+	if err != nil {
+		return err
+	}
+
+	recordAudit(models.NewAuditLogRepository(db), "token.issue", "session", session.ID, map[string]app.AuditChange{
+		"userId": {Before: nil, After: user.ID},
+	})
+
+	fmt.Println(authMiddleware.GenerateToken(user.ID, session.ID))
+
+	return nil
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"rakamin/app"
+	"rakamin/apperror"
+	"rakamin/i18n"
+	"rakamin/models"
+	"rakamin/validation"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/term"
+	"gorm.io/gorm"
+)
+
+const (
+	cliActorId   = 0
+	cliUserAgent = "rakamin-cli"
+	minPassword  = 6
+)
+
+type userCommand struct {
+	userRepo     models.UserRepository
+	sessionRepo  models.SessionRepository
+	auditLogRepo models.AuditLogRepository
+	validator    *validation.Validator
+}
+
+func runUser(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: rakamin user create|disable|enable|set-role|reset-password")
+	}
+
+	subcommand, args := args[0], args[1:]
+	flags := newFlagSet("user " + subcommand)
+	email := flags.String("email", "", "email of the new user")
+	username := flags.String("username", "", "username of the new user")
+	role := flags.String("role", models.RoleUser, "role of the new user")
+	flags.Parse(args)
+	args = flags.Args()
+
+	_, db, err := setupCommand()
+	if err != nil {
+		return err
+	}
+
+	userRepo := models.NewUserRepository(db)
+	command := userCommand{
+		userRepo:     userRepo,
+		sessionRepo:  models.NewSessionRepository(db),
+		auditLogRepo: models.NewAuditLogRepository(db),
+		validator:    validation.NewValidator(userRepo),
+	}
+
+	switch subcommand {
+	case "create":
+		return command.create(*email, *username, *role)
+	case "disable", "enable":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: rakamin user %s <id|email>", subcommand)
+		}
+
+		return command.setDisabled(args[0], subcommand == "disable")
+	case "set-role":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: rakamin user set-role <id|email> <role>")
+		}
+
+		return command.setRole(args[0], args[1])
+	case "reset-password":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: rakamin user reset-password <id|email>")
+		}
+
+		return command.resetPassword(args[0])
+	}
+
+	return fmt.Errorf("unknown user command %q", subcommand)
+}
+
+func (command userCommand) create(email, username, role string) (err error) {
+	if email == "" || username == "" {
+		return fmt.Errorf("--email and --username are required")
+	}
+	err = validateRole(role)
+	if err != nil {
+		return
+	}
+	password, err := readPassword()
+	if err != nil {
+		return
+	}
+
+	// Same rules as POST /users/register.
+	req := app.RegisterRequest{
+		Username: username,
+		Email:    email,
+		Password: password,
+	}
+	err = command.validate(&req)
+	if err != nil {
+		return
+	}
+
+	err = command.userRepo.Register(models.User{
+		Username: req.Username,
+		Email:    req.Email,
+		Password: req.Password,
+		Role:     role,
+	})
+	if err != nil {
+		return
+	}
+
+	user, err := command.userRepo.GetByEmail(req.Email)
+	if err != nil {
+		return
+	}
+
+	recordAudit(command.auditLogRepo, "user.create", "user", user.ID, map[string]app.AuditChange{
+		"username": {Before: nil, After: user.Username},
+		"email":    {Before: nil, After: user.Email},
+		"role":     {Before: nil, After: user.Role},
+	})
+	fmt.Printf("created user %d (%s, %s)\n", user.ID, user.Email, user.Role)
+
+	return
+}
+
+func (command userCommand) setDisabled(ref string, disabled bool) (err error) {
+	user, err := command.find(ref)
+	if err != nil {
+		return
+	}
+
+	err = command.userRepo.SetDisabledById(user.ID, disabled)
+	if err != nil {
+		return
+	}
+
+	action := "user.enable"
+	if disabled {
+		action = "user.disable"
+		err = command.sessionRepo.RevokeAllByUserId(user.ID)
+		if err != nil {
+			return
+		}
+	}
+
+	recordAudit(command.auditLogRepo, action, "user", user.ID, map[string]app.AuditChange{
+		"disabled": {Before: user.DisabledAt != nil, After: disabled},
+	})
+	fmt.Printf("%s user %d (%s)\n", strings.TrimPrefix(action, "user.")+"d", user.ID, user.Email)
+
+	return
+}
+
+func (command userCommand) setRole(ref, role string) (err error) {
+	err = validateRole(role)
+	if err != nil {
+		return
+	}
+
+	user, err := command.find(ref)
+	if err != nil {
+		return
+	}
+
+	err = command.userRepo.UpdateById(user.ID, models.User{Role: role})
+	if err != nil {
+		return
+	}
+
+	recordAudit(command.auditLogRepo, "user.set_role", "user", user.ID, map[string]app.AuditChange{
+		"role": {Before: user.Role, After: role},
+	})
+	fmt.Printf("user %d (%s) is now %s\n", user.ID, user.Email, role)
+
+	return
+}
+
+func (command userCommand) resetPassword(ref string) (err error) {
+	user, err := command.find(ref)
+	if err != nil {
+		return
+	}
+	password, err := readPassword()
+	if err != nil {
+		return
+	}
+
+	err = command.userRepo.UpdateById(user.ID, models.User{Password: password})
+	if err != nil {
+		return
+	}
+
+	err = command.sessionRepo.RevokeAllByUserId(user.ID)
+	if err != nil {
+		return
+	}
+
+	recordAudit(command.auditLogRepo, "user.reset_password", "user", user.ID, map[string]app.AuditChange{
+		"password": {Before: "[redacted]", After: "[redacted]"},
+	})
+	fmt.Printf("password reset for user %d (%s), sessions revoked\n", user.ID, user.Email)
+
+	return
+}
+
+// validate reports every rule the request breaks, in English.
+func (command userCommand) validate(req interface{}) error {
+	err := command.validator.ValidateStruct(req)
+
+	var validationErr validator.ValidationErrors
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+
+	messages := []string{}
+	for _, field := range i18n.FieldErrors(i18n.English, validationErr) {
+		messages = append(messages, field.Message)
+	}
+
+	return errors.New(strings.Join(messages, "; "))
+}
+
+func (command userCommand) find(ref string) (user models.User, err error) {
+	return findUser(command.userRepo, ref)
+}
+
+func recordAudit(repo models.AuditLogRepository, action, targetType string, targetId interface{}, changes map[string]app.AuditChange) {
+	auditLog := models.AuditLog{
+		ActorID:    cliActorId,
+		Actor:      cliActor(),
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   fmt.Sprintf("%v", targetId),
+		UserAgent:  cliUserAgent,
+	}
+	if len(changes) > 0 {
+		encoded, err := json.Marshal(changes)
+		if err == nil {
+			auditLog.Changes = string(encoded)
+		}
+	}
+
+	repo.Insert(auditLog)
+}
+
+func findUser(userRepo models.UserRepository, ref string) (user models.User, err error) {
+	id, err := strconv.Atoi(ref)
+	if err == nil {
+		return userRepo.GetById(id)
+	}
+
+	user, err = userRepo.GetByEmail(strings.ToLower(strings.TrimSpace(ref)))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = apperror.ErrUserNotFound.Wrap(err)
+	}
+
+	return
+}
+
+func validateRole(role string) error {
+	if role != models.RoleUser && role != models.RoleAdmin {
+		return fmt.Errorf("role must be %s or %s", models.RoleUser, models.RoleAdmin)
+	}
+
+	return nil
+}
+
+// cliActor names who ran the command, since there is no user id to record.
+func cliActor() string {
+	current, err := user.Current()
+	if err != nil || current.Username == "" {
+		return "cli"
+	}
+
+	return "cli:" + current.Username
+}
+
+// The password is never taken as a flag, since argv is readable by every
+// user on the host through ps and /proc and ends up in shell history. It is
+// prompted for without echo on a terminal and read from stdin otherwise.
+func readPassword() (password string, err error) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Fprint(os.Stderr, "Password: ")
+		var input []byte
+		input, err = term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return
+		}
+		password = strings.TrimSpace(string(input))
+	} else {
+		scanner := bufio.NewScanner(os.Stdin)
+		if scanner.Scan() {
+			password = strings.TrimSpace(scanner.Text())
+		}
+		err = scanner.Err()
+		if err != nil {
+			return
+		}
+	}
+
+	if len(password) < minPassword {
+		err = fmt.Errorf("password must be at least %d characters", minPassword)
+	}
+
+	return
+}
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"rakamin/app"
+	"rakamin/apperror"
+	"rakamin/helpers"
+	"rakamin/logger"
+	"rakamin/middlewares"
+	"rakamin/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	securityActivityLimit = 50
+	auditLogDefaultLimit  = 50
+	auditLogMaxLimit      = 500
+)
+
+type AuditLogController struct {
+	auditLogRepo   models.AuditLogRepository
+	AuthMiddleware *middlewares.AuthorizationMiddleware
+}
+
+func NewAuditLogController(auditLogRepo models.AuditLogRepository, authMiddleware *middlewares.AuthorizationMiddleware) *AuditLogController {
+	return &AuditLogController{
+		auditLogRepo:   auditLogRepo,
+		AuthMiddleware: authMiddleware,
+	}
+}
+
+func (controller *AuditLogController) GetSecurityActivity(g *gin.Context) {
+	var (
+		err error
+		id  int
+		res app.GetAllAuditLogResponse
+	)
+
+	id, err = controller.AuthMiddleware.GetUserId(g)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	data, err := controller.auditLogRepo.GetAllByUserId(id, securityActivityLimit)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	res.AuditLogs = toAuditLogs(data)
+	res.Total = int64(len(data))
+
+	response := helpers.NewSuccessResponse(res)
+	g.JSON(http.StatusOK, response)
+}
+
+func (controller *AuditLogController) GetAuditLogs(g *gin.Context) {
+	var (
+		err error
+		req app.GetAllAuditLogRequest
+		res app.GetAllAuditLogResponse
+	)
+
+	err = g.ShouldBindQuery(&req)
+	if err != nil {
+		helpers.AbortWithError(g, apperror.FromBinding(err))
+
+		return
+	}
+
+	filter := models.AuditLogFilter{
+		ActorID:    req.ActorID,
+		Action:     req.Action,
+		TargetType: req.TargetType,
+		TargetID:   req.TargetID,
+		Limit:      req.Limit,
+		Offset:     req.Offset,
+	}
+	if !req.From.IsZero() {
+		filter.From = &req.From
+	}
+	if !req.To.IsZero() {
+		filter.To = &req.To
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = auditLogDefaultLimit
+	}
+	if filter.Limit > auditLogMaxLimit {
+		filter.Limit = auditLogMaxLimit
+	}
+
+	data, total, err := controller.auditLogRepo.Find(filter)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	res.AuditLogs = toAuditLogs(data)
+	res.Total = total
+
+	response := helpers.NewSuccessResponse(res)
+	g.JSON(http.StatusOK, response)
+}
+
+func toAuditLogs(data []models.AuditLog) []app.AuditLogs {
+	var auditLog app.AuditLogs
+
+	logs := []app.AuditLogs{}
+	for _, value := range data {
+		auditLog.ID = value.ID
+		auditLog.ActorID = value.ActorID
+		auditLog.Actor = value.Actor
+		auditLog.Action = value.Action
+		auditLog.TargetType = value.TargetType
+		auditLog.TargetID = value.TargetID
+		auditLog.IP = value.IP
+		auditLog.UserAgent = value.UserAgent
+		auditLog.RequestID = value.RequestID
+		auditLog.Changes = nil
+		if value.Changes != "" {
+			json.Unmarshal([]byte(value.Changes), &auditLog.Changes)
+		}
+		auditLog.CreatedAt = *value.CreatedAt
+		logs = append(logs, auditLog)
+	}
+
+	return logs
+}
+
+func recordAudit(repo models.AuditLogRepository, g *gin.Context, actorId int, action, targetType string, targetId interface{}, changes map[string]app.AuditChange) {
+	auditLog := models.AuditLog{
+		ActorID:    actorId,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   fmt.Sprintf("%v", targetId),
+		IP:         g.ClientIP(),
+		UserAgent:  g.Request.UserAgent(),
+		RequestID:  g.GetString(logger.RequestIDKey),
+	}
+	if len(changes) > 0 {
+		encoded, err := json.Marshal(changes)
+		if err == nil {
+			auditLog.Changes = string(encoded)
+		}
+	}
+
+	repo.Insert(auditLog)
+}
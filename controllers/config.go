@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"net/http"
+	"rakamin/app"
+	"rakamin/helpers"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ConfigController struct {
+	watcher *helpers.ConfigWatcher
+}
+
+func NewConfigController(watcher *helpers.ConfigWatcher) *ConfigController {
+	return &ConfigController{
+		watcher: watcher,
+	}
+}
+
+func (controller *ConfigController) GetConfigStatus(g *gin.Context) {
+	var res app.ConfigStatusResponse
+
+	_, res.Version, res.LoadedAt = controller.watcher.Current()
+	res.Reloadable = controller.watcher.Reloadable()
+	res.History = []app.ConfigReload{}
+	for _, reload := range controller.watcher.History() {
+		res.History = append(res.History, app.ConfigReload{
+			Version:         reload.Version,
+			Source:          reload.Source,
+			Status:          reload.Status,
+			Changed:         reload.Changed,
+			RestartRequired: reload.RestartRequired,
+			Error:           reload.Error,
+			At:              reload.At,
+		})
+	}
+
+	response := helpers.NewSuccessResponse(res)
+	g.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"rakamin/app"
+	"rakamin/apperror"
+	"rakamin/helpers"
+	"rakamin/i18n"
+	"rakamin/middlewares"
+	"rakamin/models"
+	"rakamin/workers"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type DataExportController struct {
+	exportRepo     models.DataExportRepository
+	auditLogRepo   models.AuditLogRepository
+	exportWorker   *workers.DataExportWorker
+	AuthMiddleware *middlewares.AuthorizationMiddleware
+}
+
+func NewDataExportController(exportRepo models.DataExportRepository, auditLogRepo models.AuditLogRepository, exportWorker *workers.DataExportWorker, authMiddleware *middlewares.AuthorizationMiddleware) *DataExportController {
+	return &DataExportController{
+		exportRepo:     exportRepo,
+		auditLogRepo:   auditLogRepo,
+		exportWorker:   exportWorker,
+		AuthMiddleware: authMiddleware,
+	}
+}
+
+func (controller *DataExportController) RequestExport(g *gin.Context) {
+	var (
+		err error
+		id  int
+	)
+
+	id, err = controller.AuthMiddleware.GetUserId(g)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	export := models.DataExport{
+		ID:     helpers.GetUUID(),
+		UserID: id,
+		Status: models.ExportStatusPending,
+	}
+	err = controller.exportRepo.Insert(export)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	err = controller.exportWorker.Enqueue(export)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	recordAudit(controller.auditLogRepo, g, id, "user.export", "export", export.ID, nil)
+
+	response := helpers.NewSuccessInsertResponse(app.DataExportResponse{
+		ID:        export.ID,
+		Status:    export.Status,
+		CreatedAt: time.Now(),
+	})
+	g.JSON(http.StatusAccepted, response)
+}
+
+func (controller *DataExportController) GetExportById(g *gin.Context) {
+	var (
+		err error
+		id  int
+		req app.GetDataExportByIdRequest
+		res app.DataExportResponse
+	)
+
+	id, err = controller.AuthMiddleware.GetUserId(g)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	err = g.ShouldBindUri(&req)
+	if err != nil {
+		helpers.AbortWithError(g, apperror.FromBinding(err))
+
+		return
+	}
+
+	data, err := controller.exportRepo.GetById(id, req.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		helpers.AbortWithError(g, apperror.ErrExportNotFound)
+
+		return
+	}
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	res.ID = data.ID
+	res.Status = data.Status
+	res.ErrorCode = data.Error
+	res.Error = localizeCode(i18n.FromContext(g), data.Error)
+	res.ExpiresAt = data.ExpiresAt
+	res.CreatedAt = *data.CreatedAt
+	if data.Status == models.ExportStatusReady && data.ExpiresAt != nil && data.ExpiresAt.After(time.Now()) {
+		// Only a hash of the token is kept, so every read hands out a fresh
+		// link and the previous one stops working.
+		secret, err := helpers.GetRandomToken(32)
+		if err != nil {
+			helpers.AbortWithError(g, err)
+
+			return
+		}
+
+		data.TokenHash = helpers.HashToken(secret)
+		err = controller.exportRepo.Update(data)
+		if err != nil {
+			helpers.AbortWithError(g, err)
+
+			return
+		}
+		res.DownloadURL = fmt.Sprintf("/api/v1/exports/%s.%s/download", data.ID, secret)
+	}
+
+	response := helpers.NewSuccessResponse(res)
+	g.JSON(http.StatusOK, response)
+}
+
+func (controller *DataExportController) Download(g *gin.Context) {
+	var (
+		err error
+		req app.DownloadDataExportRequest
+	)
+
+	err = g.ShouldBindUri(&req)
+	if err != nil {
+		helpers.AbortWithError(g, apperror.FromBinding(err))
+
+		return
+	}
+
+	id, secret, _ := strings.Cut(req.Token, ".")
+	data, err := controller.exportRepo.GetReadyById(id)
+	if err != nil || data.TokenHash == "" || !helpers.TokenMatches(secret, data.TokenHash) || data.ExpiresAt == nil || data.ExpiresAt.Before(time.Now()) {
+		helpers.AbortWithError(g, apperror.ErrExportLinkExpired)
+
+		return
+	}
+
+	g.Header("Cache-Control", "private, no-store")
+	g.FileAttachment(data.FilePath, fmt.Sprintf("export-%s.zip", data.ID))
+}
@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"net/http"
+	"rakamin/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DocsController struct {
+	spec map[string]interface{}
+}
+
+func NewDocsController() *DocsController {
+	return &DocsController{
+		spec: openapi.Build(),
+	}
+}
+
+func (controller *DocsController) Spec(g *gin.Context) {
+	g.JSON(http.StatusOK, controller.spec)
+}
+
+func (controller *DocsController) UI(g *gin.Context) {
+	g.Data(http.StatusOK, "text/html; charset=utf-8", openapi.DocsPage)
+}
@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"rakamin/events"
+	"rakamin/helpers"
+	"rakamin/middlewares"
+	"rakamin/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+	"gorm.io/gorm"
+)
+
+const eventHeartbeatInterval = 25 * time.Second
+
+type EventController struct {
+	hub            *events.Hub
+	sessionRepo    models.SessionRepository
+	AuthMiddleware *middlewares.AuthorizationMiddleware
+}
+
+func NewEventController(hub *events.Hub, sessionRepo models.SessionRepository, authMiddleware *middlewares.AuthorizationMiddleware) *EventController {
+	return &EventController{
+		hub:            hub,
+		sessionRepo:    sessionRepo,
+		AuthMiddleware: authMiddleware,
+	}
+}
+
+func (controller *EventController) Stream(g *gin.Context) {
+	id, err := controller.AuthMiddleware.GetUserId(g)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	sessionId, err := controller.AuthMiddleware.GetSessionId(g)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	lastEventId := g.GetHeader("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = g.Query("lastEventId")
+	}
+
+	stream, missed, cancel := controller.hub.Subscribe(id, lastEventId)
+	defer cancel()
+
+	g.Header("Content-Type", "text/event-stream")
+	g.Header("Cache-Control", "no-cache")
+	g.Header("Connection", "keep-alive")
+	g.Header("X-Accel-Buffering", "no")
+	g.Status(http.StatusOK)
+
+	for _, event := range missed {
+		writeServerSentEvent(g.Writer, event)
+	}
+	g.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-g.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			if controller.sessionRevoked(sessionId) {
+				return
+			}
+			fmt.Fprint(g.Writer, ": heartbeat\n\n")
+			g.Writer.Flush()
+		case event, ok := <-stream:
+			if !ok {
+				return
+			}
+			writeServerSentEvent(g.Writer, event)
+			g.Writer.Flush()
+		}
+	}
+}
+
+func (controller *EventController) WebSocket(g *gin.Context) {
+	id, err := controller.AuthMiddleware.GetUserId(g)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	sessionId, err := controller.AuthMiddleware.GetSessionId(g)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	lastEventId := g.Query("lastEventId")
+
+	websocket.Handler(func(conn *websocket.Conn) {
+		defer conn.Close()
+
+		stream, missed, cancel := controller.hub.Subscribe(id, lastEventId)
+		defer cancel()
+
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			io.Copy(io.Discard, conn)
+		}()
+
+		for _, event := range missed {
+			if websocket.JSON.Send(conn, event) != nil {
+				return
+			}
+		}
+
+		sessionCheck := time.NewTicker(eventHeartbeatInterval)
+		defer sessionCheck.Stop()
+
+		for {
+			select {
+			case <-closed:
+				return
+			case <-sessionCheck.C:
+				if controller.sessionRevoked(sessionId) {
+					return
+				}
+			case event, ok := <-stream:
+				if !ok || websocket.JSON.Send(conn, event) != nil {
+					return
+				}
+			}
+		}
+	}).ServeHTTP(g.Writer, g.Request)
+}
+
+// sessionRevoked is checked on an interval so streams opened before a logout
+// or revoke don't outlive the session. Lookup failures other than a missing
+// row keep the stream open rather than dropping every client on a db blip.
+func (controller *EventController) sessionRevoked(sessionId string) bool {
+	session, err := controller.sessionRepo.GetById(sessionId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return true
+	}
+
+	return err == nil && session.RevokedAt != nil
+}
+
+func writeServerSentEvent(w io.Writer, event events.Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+}
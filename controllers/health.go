@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"rakamin/app"
+	"rakamin/apperror"
+	"rakamin/helpers"
+	"rakamin/i18n"
+	"rakamin/logger"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const readinessTimeout = 5 * time.Second
+
+type HealthCheck func(ctx context.Context) error
+
+type HealthController struct {
+	checks       map[string]HealthCheck
+	shuttingDown *int32
+}
+
+func NewHealthController(checks map[string]HealthCheck) *HealthController {
+	return &HealthController{
+		checks:       checks,
+		shuttingDown: new(int32),
+	}
+}
+
+func (controller *HealthController) SetShuttingDown() {
+	atomic.StoreInt32(controller.shuttingDown, 1)
+}
+
+func (controller *HealthController) Live(g *gin.Context) {
+	g.JSON(http.StatusOK, helpers.NewSuccessResponse(app.HealthResponse{Status: "ok"}))
+}
+
+func (controller *HealthController) Ready(g *gin.Context) {
+	res := app.HealthResponse{
+		Status: "ok",
+		Checks: map[string]string{},
+	}
+
+	if atomic.LoadInt32(controller.shuttingDown) == 1 {
+		res.Status = "shutting down"
+	}
+
+	ctx, cancel := context.WithTimeout(g.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	names := make([]string, 0, len(controller.checks))
+	for name := range controller.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		err := controller.checks[name](ctx)
+		if err != nil {
+			// The endpoint is public, so the reason only goes to the log.
+			logger.FromContext(g.Request.Context()).Error().Err(err).Str("check", name).Msg("readiness check failed")
+			res.Status = "unavailable"
+			res.Checks[name] = "failing"
+
+			continue
+		}
+
+		res.Checks[name] = "ok"
+	}
+
+	if res.Status != "ok" {
+		response := helpers.NewLocalizedErrorResponse(i18n.FromContext(g), apperror.ErrNotReady)
+		response.Data = res
+		g.AbortWithStatusJSON(http.StatusServiceUnavailable, response)
+
+		return
+	}
+
+	g.JSON(http.StatusOK, helpers.NewSuccessResponse(res))
+}
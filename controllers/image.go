@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"rakamin/apperror"
+	"rakamin/helpers"
+	"rakamin/models"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Files under the image dir are named by a fresh uuid on every write and
+// never change afterwards, so their URLs can be cached for good.
+const immutableCacheControl = "public, max-age=31536000, immutable"
+
+type ImageController struct {
+	photoRepo models.PhotoRepository
+}
+
+func NewImageController(photoRepo models.PhotoRepository) *ImageController {
+	return &ImageController{
+		photoRepo: photoRepo,
+	}
+}
+
+// Serve delivers files from the image dir. JPEG and PNG photos are answered
+// with their WebP variant when the client accepts it. Files of trashed photos
+// stay on disk until purged but are no longer served.
+func (controller *ImageController) Serve(g *gin.Context) {
+	path := filepath.Join(helpers.ImageDir, filepath.Clean("/"+g.Param("filepath")))
+
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		helpers.AbortWithError(g, apperror.ErrNotFound)
+
+		return
+	}
+
+	photo, err := controller.photoRepo.WithContext(g.Request.Context()).GetByFileName(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	if err != nil {
+		if errors.Is(err, apperror.ErrPhotoNotFound) {
+			err = apperror.ErrNotFound
+		}
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	filetype := mime.TypeByExtension(filepath.Ext(path))
+	if filetype == helpers.ImageTypeJPEG || filetype == helpers.ImageTypePNG {
+		g.Header("Vary", "Accept")
+
+		if acceptsType(g.GetHeader("Accept"), helpers.ImageTypeWebP) {
+			variant, err := helpers.VariantPath(path, helpers.ImageTypeWebP)
+			if err == nil {
+				if variantInfo, err := os.Stat(variant); err == nil {
+					path, info = variant, variantInfo
+				}
+			}
+		}
+	}
+
+	serveFile(g, path, filepath.Base(path), photoETag(photo, path, info), immutableCacheControl)
+}
+
+// photoETag reuses the hash taken on upload for the stored file. Variants
+// are derived from it, so theirs adds the file name, size and write time.
+func photoETag(photo models.Photo, path string, info os.FileInfo) string {
+	if path == photo.OriginalURL || (path == photo.PhotoURL && photo.OriginalURL == "") {
+		return strongETag(photo.Hash)
+	}
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%s:%d:%d", photo.Hash, filepath.Base(path), info.Size(), info.ModTime().UnixNano())))
+
+	return strongETag(hex.EncodeToString(sum[:]))
+}
+
+func serveFile(g *gin.Context, path, name, etag, cacheControl string) {
+	file, err := os.Open(path)
+	if err != nil {
+		helpers.AbortWithError(g, apperror.ErrNotFound)
+
+		return
+	}
+	defer file.Close()
+
+	serveContent(g, file, name, etag, cacheControl)
+}
+
+// serveContent leaves If-None-Match, If-Modified-Since and Range handling to
+// http.ServeContent, which checks them against the ETag set here. A
+// download query parameter turns the response into an attachment.
+func serveContent(g *gin.Context, file *os.File, name, etag, cacheControl string) {
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		helpers.AbortWithError(g, apperror.ErrNotFound)
+
+		return
+	}
+
+	disposition := "inline"
+	if download, ok := g.GetQuery("download"); ok && download != "0" && download != "false" {
+		disposition = "attachment"
+	}
+
+	header := g.Writer.Header()
+	header.Set("Content-Type", mime.TypeByExtension(filepath.Ext(name)))
+	header.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": name}))
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", cacheControl)
+
+	http.ServeContent(g.Writer, g.Request, name, info.ModTime(), file)
+}
+
+// acceptsType only honours the type when it is listed explicitly, since
+// wildcards say nothing about whether a client can decode it.
+func acceptsType(accept, filetype string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || mediaType != filetype {
+			continue
+		}
+
+		q, err := strconv.ParseFloat(params["q"], 64)
+		return err != nil || q > 0
+	}
+
+	return false
+}
+
+func strongETag(hash string) string {
+	if len(hash) > 32 {
+		hash = hash[:32]
+	}
+
+	return `"` + hash + `"`
+}
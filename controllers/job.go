@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"rakamin/app"
+	"rakamin/apperror"
+	"rakamin/helpers"
+	"rakamin/middlewares"
+	"rakamin/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	jobDefaultLimit = 50
+	jobMaxLimit     = 500
+)
+
+type JobController struct {
+	jobRepo        models.JobRepository
+	auditLogRepo   models.AuditLogRepository
+	AuthMiddleware *middlewares.AuthorizationMiddleware
+}
+
+func NewJobController(jobRepo models.JobRepository, auditLogRepo models.AuditLogRepository, authMiddleware *middlewares.AuthorizationMiddleware) *JobController {
+	return &JobController{
+		jobRepo:        jobRepo,
+		auditLogRepo:   auditLogRepo,
+		AuthMiddleware: authMiddleware,
+	}
+}
+
+func (controller *JobController) GetJobs(g *gin.Context) {
+	var (
+		err error
+		req app.GetAllJobRequest
+		res app.GetAllJobResponse
+	)
+
+	err = g.ShouldBindQuery(&req)
+	if err != nil {
+		helpers.AbortWithError(g, apperror.FromBinding(err))
+
+		return
+	}
+
+	filter := models.JobFilter{
+		Type:   req.Type,
+		Status: req.Status,
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = jobDefaultLimit
+	}
+	if filter.Limit > jobMaxLimit {
+		filter.Limit = jobMaxLimit
+	}
+
+	data, total, err := controller.jobRepo.Find(filter)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	res.Counts, err = controller.jobRepo.CountByStatus()
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	res.Jobs = []app.Jobs{}
+	for _, value := range data {
+		res.Jobs = append(res.Jobs, toJob(value))
+	}
+	res.Total = total
+
+	response := helpers.NewSuccessResponse(res)
+	g.JSON(http.StatusOK, response)
+}
+
+func (controller *JobController) GetJobById(g *gin.Context) {
+	var (
+		err error
+		req app.JobByIdRequest
+	)
+
+	err = g.ShouldBindUri(&req)
+	if err != nil {
+		helpers.AbortWithError(g, apperror.FromBinding(err))
+
+		return
+	}
+
+	data, err := controller.jobRepo.GetById(req.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		helpers.AbortWithError(g, apperror.ErrJobNotFound)
+
+		return
+	}
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	response := helpers.NewSuccessResponse(toJob(data))
+	g.JSON(http.StatusOK, response)
+}
+
+func (controller *JobController) RetryJobById(g *gin.Context) {
+	var (
+		err error
+		id  int
+		req app.JobByIdRequest
+	)
+
+	id, err = controller.AuthMiddleware.GetUserId(g)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	err = g.ShouldBindUri(&req)
+	if err != nil {
+		helpers.AbortWithError(g, apperror.FromBinding(err))
+
+		return
+	}
+
+	err = controller.jobRepo.Retry(req.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		helpers.AbortWithError(g, apperror.ErrJobNotRetryable)
+
+		return
+	}
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	recordAudit(controller.auditLogRepo, g, id, "job.retry", "job", req.ID, nil)
+
+	response := helpers.NewSuccessResponse(nil)
+	g.JSON(http.StatusOK, response)
+}
+
+func toJob(value models.Job) app.Jobs {
+	job := app.Jobs{
+		ID:          value.ID,
+		Type:        value.Type,
+		Payload:     value.Payload,
+		Status:      value.Status,
+		Attempts:    value.Attempts,
+		MaxAttempts: value.MaxAttempts,
+		LastError:   value.LastError,
+		RunAt:       value.RunAt,
+		LockedAt:    value.LockedAt,
+		LockedBy:    value.LockedBy,
+	}
+	if value.CreatedAt != nil {
+		job.CreatedAt = *value.CreatedAt
+	}
+
+	return job
+}
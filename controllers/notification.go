@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"net/http"
+	"rakamin/app"
+	"rakamin/helpers"
+	"rakamin/middlewares"
+	"rakamin/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationController struct {
+	notificationRepo models.NotificationRepository
+	AuthMiddleware   *middlewares.AuthorizationMiddleware
+}
+
+func NewNotificationController(notificationRepo models.NotificationRepository, authMiddleware *middlewares.AuthorizationMiddleware) *NotificationController {
+	return &NotificationController{
+		notificationRepo: notificationRepo,
+		AuthMiddleware:   authMiddleware,
+	}
+}
+
+func (controller *NotificationController) GetNotifications(g *gin.Context) {
+	var (
+		err          error
+		id           int
+		notification app.Notifications
+		res          app.GetAllNotificationResponse
+	)
+
+	id, err = controller.AuthMiddleware.GetUserId(g)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	data, err := controller.notificationRepo.GetAllByUserId(id)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	res.Notifications = []app.Notifications{}
+	for _, value := range data {
+		notification.ID = value.ID
+		notification.Title = value.Title
+		notification.Message = value.Message
+		notification.ReadAt = value.ReadAt
+		notification.CreatedAt = *value.CreatedAt
+		res.Notifications = append(res.Notifications, notification)
+	}
+
+	response := helpers.NewSuccessResponse(res)
+	g.JSON(http.StatusOK, response)
+}
@@ -2,28 +2,48 @@ package controllers
 
 import (
 	"errors"
-	"fmt"
-	"io/ioutil"
-	"mime"
 	"net/http"
+	"os"
 	"rakamin/app"
+	"rakamin/apperror"
+	"rakamin/events"
 	"rakamin/helpers"
+	"rakamin/logger"
+	"rakamin/metrics"
 	"rakamin/middlewares"
 	"rakamin/models"
+	"rakamin/quota"
+	"rakamin/tracing"
+	"rakamin/workers"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
 )
 
 type PhotoController struct {
 	photoRepo      models.PhotoRepository
+	auditLogRepo   models.AuditLogRepository
+	photoProcessor *workers.PhotoProcessor
+	quotaChecker   *quota.Checker
+	imageStore     *helpers.ImageStore
+	publisher      events.Publisher
 	AuthMiddleware *middlewares.AuthorizationMiddleware
+	gracePeriod    time.Duration
 }
 
-func NewPhotoController(photoRepo models.PhotoRepository, authMiddleware *middlewares.AuthorizationMiddleware) *PhotoController {
+func NewPhotoController(photoRepo models.PhotoRepository, auditLogRepo models.AuditLogRepository, photoProcessor *workers.PhotoProcessor, quotaChecker *quota.Checker, imageStore *helpers.ImageStore, publisher events.Publisher, authMiddleware *middlewares.AuthorizationMiddleware, gracePeriod time.Duration) *PhotoController {
 	return &PhotoController{
 		photoRepo:      photoRepo,
+		auditLogRepo:   auditLogRepo,
+		photoProcessor: photoProcessor,
+		quotaChecker:   quotaChecker,
+		imageStore:     imageStore,
+		publisher:      publisher,
 		AuthMiddleware: authMiddleware,
+		gracePeriod:    gracePeriod,
 	}
 }
 
@@ -36,68 +56,94 @@ func (controller *PhotoController) Upload(g *gin.Context) {
 
 	id, err = controller.AuthMiddleware.GetUserId(g)
 	if err != nil {
+		helpers.AbortWithError(g, err)
+
 		return
 	}
 
 	err = g.ShouldBind(&request)
 	if err != nil {
-		response := helpers.NewErrorResponse(err)
-		g.AbortWithStatusJSON(http.StatusBadRequest, response)
+		helpers.AbortWithError(g, apperror.FromBinding(err))
 
 		return
 	}
 
-	src, err := request.Photo.Open()
+	metrics.UploadSize.Observe(float64(request.Photo.Size))
+
+	err = controller.quotaChecker.Check(g.Request.Context(), id, request.Photo.Size, 0, true)
 	if err != nil {
-		response := helpers.NewErrorResponse(errors.New("can't open file"))
-		g.AbortWithStatusJSON(http.StatusInternalServerError, response)
+		helpers.AbortWithError(g, err)
 
 		return
 	}
 
-	defer src.Close()
-
-	fileBytes, err := ioutil.ReadAll(src)
+	src, err := request.Photo.Open()
 	if err != nil {
-		response := helpers.NewErrorResponse(errors.New("invalid file"))
-		g.AbortWithStatusJSON(http.StatusInternalServerError, response)
+		helpers.AbortWithError(g, apperror.ErrFileUnreadable)
 
 		return
 	}
 
-	filetype := http.DetectContentType(fileBytes)
-	if filetype != "image/jpeg" && filetype != "image/jpg" &&
-		filetype != "image/gif" && filetype != "image/png" {
-		response := helpers.NewErrorResponse(errors.New("invalid file type"))
-		g.AbortWithStatusJSON(http.StatusInternalServerError, response)
-
-		return
-	}
+	defer src.Close()
 
-	fileFormat, err := mime.ExtensionsByType(filetype)
+	ctx, span := tracing.Start(g.Request.Context(), "storage.save", attribute.Int64("file.size", request.Photo.Size))
+	stored, err := controller.imageStore.Save(ctx, src, request.Photo.Size)
+	tracing.End(span, err)
 	if err != nil {
-		response := helpers.NewErrorResponse(errors.New("can't read File Type"))
-		g.AbortWithStatusJSON(http.StatusInternalServerError, response)
+		if stored.Filetype != "" {
+			metrics.UploadFileTypes.WithLabelValues(stored.Filetype, "rejected").Inc()
+		}
+		helpers.AbortWithError(g, err)
 
 		return
 	}
+	metrics.UploadFileTypes.WithLabelValues(stored.Filetype, "accepted").Inc()
 
-	path := fmt.Sprintf("%s%s%s", "public/images/", helpers.GetUUID(), fileFormat[len(fileFormat)-1])
-
-	err = controller.photoRepo.Insert(models.Photo{
+	photoId, err := controller.photoRepo.WithContext(g.Request.Context()).Insert(models.Photo{
 		Title:    request.Title,
 		Caption:  request.Caption,
-		PhotoURL: path,
+		Tags:     request.Tags,
+		Album:    request.Album,
+		PhotoURL: stored.Path,
+		Status:   models.PhotoStatusPending,
+		Hash:     stored.Hash,
+		Size:     stored.Size,
+		Width:    stored.Width,
+		Height:   stored.Height,
 		UserID:   id,
 	})
 	if err != nil {
-		response := helpers.NewErrorResponse(err)
-		g.JSON(http.StatusInternalServerError, response)
+		os.Remove(stored.Path)
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	err = controller.photoProcessor.Enqueue(photoId, id)
+	if err != nil {
+		// Nothing would ever process the photo, so don't keep it around as
+		// pending.
+		purgeErr := controller.photoRepo.WithContext(g.Request.Context()).PurgeById(photoId)
+		if purgeErr == nil {
+			os.Remove(stored.Path)
+		} else {
+			logger.FromContext(g.Request.Context()).Error().Err(purgeErr).Int("photoId", photoId).Msg("removing unqueued photo")
+		}
+		helpers.AbortWithError(g, err)
 
 		return
 	}
 
-	g.SaveUploadedFile(request.Photo, path)
+	controller.publisher.Publish(events.New(events.PhotoCreated, id, app.Photos{
+		ID:       photoId,
+		Title:    request.Title,
+		Caption:  request.Caption,
+		PhotoURL: stored.Path,
+		Tags:     request.Tags,
+		Album:    request.Album,
+		Status:   models.PhotoStatusPending,
+		UserID:   id,
+	}))
 
 	response := helpers.NewSuccessInsertResponse(nil)
 	g.JSON(http.StatusCreated, response)
@@ -113,13 +159,14 @@ func (controller *PhotoController) GetPhotos(g *gin.Context) {
 
 	id, err = controller.AuthMiddleware.GetUserId(g)
 	if err != nil {
+		helpers.AbortWithError(g, err)
+
 		return
 	}
 
-	data, err := controller.photoRepo.GetAllByUserId(id)
+	data, err := controller.photoRepo.WithContext(g.Request.Context()).GetAllByUserId(id)
 	if err != nil {
-		response := helpers.NewErrorResponse(err)
-		g.JSON(http.StatusInternalServerError, response)
+		helpers.AbortWithError(g, err)
 
 		return
 	}
@@ -129,6 +176,10 @@ func (controller *PhotoController) GetPhotos(g *gin.Context) {
 		photo.Title = value.Title
 		photo.Caption = value.Caption
 		photo.PhotoURL = value.PhotoURL
+		photo.OriginalURL = value.OriginalURL
+		photo.Tags = value.Tags
+		photo.Album = value.Album
+		photo.Status = value.Status
 		photo.UserID = value.UserID
 		res.Photos = append(res.Photos, photo)
 	}
@@ -146,79 +197,119 @@ func (controller *PhotoController) UpdatePhotoById(g *gin.Context) {
 
 	id, err = controller.AuthMiddleware.GetUserId(g)
 	if err != nil {
+		helpers.AbortWithError(g, err)
+
 		return
 	}
 
 	err = g.ShouldBind(&req)
 	if err != nil {
-		response := helpers.NewErrorResponse(err)
-		g.AbortWithStatusJSON(http.StatusBadRequest, response)
+		helpers.AbortWithError(g, apperror.FromBinding(err))
 
 		return
 	}
 
 	pid := g.Param("photoId")
 
-	photoId, _ := strconv.Atoi(pid)
+	photoId, err := strconv.Atoi(pid)
 	if err != nil {
-		response := helpers.NewErrorResponse(errors.New("cant parse photo id"))
-		g.AbortWithStatusJSON(http.StatusBadRequest, response)
+		helpers.AbortWithError(g, apperror.ErrInvalidPhotoId)
 
 		return
 	}
-	src, err := req.Photo.Open()
+
+	before, err := controller.photoRepo.WithContext(g.Request.Context()).GetById(id, photoId)
 	if err != nil {
-		response := helpers.NewErrorResponse(errors.New("can't open file"))
-		g.AbortWithStatusJSON(http.StatusInternalServerError, response)
+		helpers.AbortWithError(g, err)
 
 		return
 	}
 
-	defer src.Close()
+	metrics.UploadSize.Observe(float64(req.Photo.Size))
 
-	fileBytes, err := ioutil.ReadAll(src)
+	err = controller.quotaChecker.Check(g.Request.Context(), id, req.Photo.Size, before.Size+before.VariantBytes, false)
 	if err != nil {
-		response := helpers.NewErrorResponse(errors.New("invalid file"))
-		g.AbortWithStatusJSON(http.StatusInternalServerError, response)
+		helpers.AbortWithError(g, err)
 
 		return
 	}
 
-	filetype := http.DetectContentType(fileBytes)
-	if filetype != "image/jpeg" && filetype != "image/jpg" &&
-		filetype != "image/gif" && filetype != "image/png" {
-		response := helpers.NewErrorResponse(errors.New("invalid file type"))
-		g.AbortWithStatusJSON(http.StatusInternalServerError, response)
+	src, err := req.Photo.Open()
+	if err != nil {
+		helpers.AbortWithError(g, apperror.ErrFileUnreadable)
 
 		return
 	}
 
-	fileFormat, err := mime.ExtensionsByType(filetype)
+	defer src.Close()
+
+	ctx, span := tracing.Start(g.Request.Context(), "storage.save", attribute.Int64("file.size", req.Photo.Size))
+	stored, err := controller.imageStore.Save(ctx, src, req.Photo.Size)
+	tracing.End(span, err)
 	if err != nil {
-		response := helpers.NewErrorResponse(errors.New("can't read File Type"))
-		g.AbortWithStatusJSON(http.StatusInternalServerError, response)
+		if stored.Filetype != "" {
+			metrics.UploadFileTypes.WithLabelValues(stored.Filetype, "rejected").Inc()
+		}
+		helpers.AbortWithError(g, err)
 
 		return
 	}
+	metrics.UploadFileTypes.WithLabelValues(stored.Filetype, "accepted").Inc()
 
-	path := fmt.Sprintf("%s%s%s", "public/images/", helpers.GetUUID(), fileFormat[len(fileFormat)-1])
-
-	err = controller.photoRepo.UpdatePhotoById(models.Photo{
+	err = controller.photoRepo.WithContext(g.Request.Context()).ReplaceById(models.Photo{
 		ID:       photoId,
 		Title:    req.Title,
 		Caption:  req.Caption,
-		PhotoURL: path,
+		Tags:     req.Tags,
+		Album:    req.Album,
+		PhotoURL: stored.Path,
+		Status:   models.PhotoStatusPending,
+		Hash:     stored.Hash,
+		Size:     stored.Size,
+		Width:    stored.Width,
+		Height:   stored.Height,
 		UserID:   id,
 	})
 	if err != nil {
-		response := helpers.NewErrorResponse(err)
-		g.JSON(http.StatusInternalServerError, response)
+		os.Remove(stored.Path)
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	err = controller.photoProcessor.Enqueue(photoId, id)
+	if err != nil {
+		// Nothing would ever process the new file, so put the previous one
+		// back rather than leave the photo pending.
+		rollbackErr := controller.photoRepo.WithContext(g.Request.Context()).ReplaceById(before)
+		if rollbackErr == nil {
+			os.Remove(stored.Path)
+		} else {
+			logger.FromContext(g.Request.Context()).Error().Err(rollbackErr).Int("photoId", photoId).Msg("restoring unqueued photo")
+		}
+		helpers.AbortWithError(g, err)
 
 		return
 	}
 
-	g.SaveUploadedFile(req.Photo, path)
-	
+	// The row no longer points at the previous file, so its usage would
+	// otherwise count against the quota forever.
+	err = helpers.RemoveImageFiles(before.PhotoURL, before.OriginalURL)
+	if err != nil {
+		logger.FromContext(g.Request.Context()).Error().Err(err).Int("photoId", photoId).Msg("removing replaced photo files")
+	}
+
+	controller.publisher.Publish(events.New(events.PhotoUpdated, id, app.Photos{
+		ID:       photoId,
+		Title:    req.Title,
+		Caption:  req.Caption,
+		PhotoURL: stored.Path,
+		Tags:     req.Tags,
+		Album:    req.Album,
+		Status:   models.PhotoStatusPending,
+		UserID:   id,
+	}))
+
 	response := helpers.NewSuccessResponse(nil)
 	g.JSON(http.StatusOK, response)
 }
@@ -232,24 +323,124 @@ func (controller *PhotoController) DeletePhotoById(g *gin.Context) {
 
 	id, err = controller.AuthMiddleware.GetUserId(g)
 	if err != nil {
+		helpers.AbortWithError(g, err)
+
 		return
 	}
 
 	err = g.ShouldBindUri(&req)
 	if err != nil {
-		response := helpers.NewErrorResponse(err)
-		g.AbortWithStatusJSON(http.StatusBadRequest, response)
+		helpers.AbortWithError(g, apperror.FromBinding(err))
+
+		return
+	}
+
+	before, err := controller.photoRepo.WithContext(g.Request.Context()).GetById(id, req.ID)
+	if err != nil {
+		helpers.AbortWithError(g, err)
 
 		return
 	}
-	err = controller.photoRepo.DeletePhotoById(id, req.ID)
+
+	err = controller.photoRepo.WithContext(g.Request.Context()).DeletePhotoById(id, req.ID)
 	if err != nil {
-		response := helpers.NewErrorResponse(err)
-		g.JSON(http.StatusInternalServerError, response)
+		helpers.AbortWithError(g, err)
 
 		return
 	}
 
+	recordAudit(controller.auditLogRepo, g, id, "photo.delete", "photo", req.ID, map[string]app.AuditChange{
+		"title":    {Before: before.Title, After: nil},
+		"photoUrl": {Before: before.PhotoURL, After: nil},
+	})
+	controller.publisher.Publish(events.New(events.PhotoDeleted, id, app.Photos{
+		ID:       before.ID,
+		Title:    before.Title,
+		Caption:  before.Caption,
+		PhotoURL: before.PhotoURL,
+		Tags:     before.Tags,
+		Album:    before.Album,
+		Status:   before.Status,
+		UserID:   before.UserID,
+	}))
+
+	response := helpers.NewSuccessResponse(nil)
+	g.JSON(http.StatusOK, response)
+}
+
+func (controller *PhotoController) GetTrash(g *gin.Context) {
+	var (
+		err   error
+		id    int
+		photo app.TrashPhotos
+		res   app.GetAllTrashPhotoResponse
+	)
+
+	id, err = controller.AuthMiddleware.GetUserId(g)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	data, err := controller.photoRepo.WithContext(g.Request.Context()).GetTrashByUserId(id)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	res.Photos = []app.TrashPhotos{}
+	for _, value := range data {
+		photo.ID = value.ID
+		photo.Title = value.Title
+		photo.Caption = value.Caption
+		photo.PhotoURL = value.PhotoURL
+		photo.UserID = value.UserID
+		photo.DeletedAt = value.DeletedAt.Time
+		photo.PurgeAt = value.DeletedAt.Time.Add(controller.gracePeriod)
+		res.Photos = append(res.Photos, photo)
+	}
+
+	response := helpers.NewSuccessResponse(res)
+	g.JSON(http.StatusOK, response)
+}
+
+func (controller *PhotoController) RestorePhotoById(g *gin.Context) {
+	var (
+		err error
+		id  int
+		req app.RestorePhotoByIdRequest
+	)
+
+	id, err = controller.AuthMiddleware.GetUserId(g)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	err = g.ShouldBindUri(&req)
+	if err != nil {
+		helpers.AbortWithError(g, apperror.FromBinding(err))
+
+		return
+	}
+
+	err = controller.photoRepo.WithContext(g.Request.Context()).RestorePhotoById(id, req.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		helpers.AbortWithError(g, apperror.ErrPhotoNotInTrash)
+
+		return
+	}
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	recordAudit(controller.auditLogRepo, g, id, "photo.restore", "photo", req.ID, nil)
+
 	response := helpers.NewSuccessResponse(nil)
 	g.JSON(http.StatusOK, response)
 }
@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"rakamin/app"
+	"rakamin/apperror"
+	"rakamin/helpers"
+	"rakamin/i18n"
+	"rakamin/middlewares"
+	"rakamin/models"
+	"rakamin/workers"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type PhotoImportController struct {
+	importRepo     models.PhotoImportRepository
+	importWorker   *workers.PhotoImportWorker
+	AuthMiddleware *middlewares.AuthorizationMiddleware
+	dir            string
+}
+
+func NewPhotoImportController(importRepo models.PhotoImportRepository, importWorker *workers.PhotoImportWorker, authMiddleware *middlewares.AuthorizationMiddleware, dir string) *PhotoImportController {
+	return &PhotoImportController{
+		importRepo:     importRepo,
+		importWorker:   importWorker,
+		AuthMiddleware: authMiddleware,
+		dir:            dir,
+	}
+}
+
+func (controller *PhotoImportController) Import(g *gin.Context) {
+	var (
+		err     error
+		id      int
+		request app.PhotoImportRequest
+	)
+
+	id, err = controller.AuthMiddleware.GetUserId(g)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	err = g.ShouldBind(&request)
+	if err != nil {
+		helpers.AbortWithError(g, apperror.FromBinding(err))
+
+		return
+	}
+
+	if filepath.Ext(request.Archive.Filename) != ".zip" {
+		helpers.AbortWithError(g, apperror.ErrInvalidArchive)
+
+		return
+	}
+
+	photoImport := models.PhotoImport{
+		ID:     helpers.GetUUID(),
+		UserID: id,
+		Status: models.ImportStatusPending,
+	}
+	photoImport.ArchivePath = filepath.Join(controller.dir, photoImport.ID+".zip")
+
+	err = g.SaveUploadedFile(request.Archive, photoImport.ArchivePath)
+	if err != nil {
+		helpers.AbortWithError(g, apperror.ErrFileNotSaved)
+
+		return
+	}
+
+	err = controller.importRepo.Insert(photoImport)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	err = controller.importWorker.Enqueue(photoImport)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	response := helpers.NewSuccessInsertResponse(app.PhotoImportResponse{
+		ID:        photoImport.ID,
+		Status:    photoImport.Status,
+		CreatedAt: time.Now(),
+	})
+	g.JSON(http.StatusAccepted, response)
+}
+
+func (controller *PhotoImportController) GetImportById(g *gin.Context) {
+	var (
+		err error
+		id  int
+		req app.GetPhotoImportByIdRequest
+		res app.PhotoImportResponse
+	)
+
+	id, err = controller.AuthMiddleware.GetUserId(g)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	err = g.ShouldBindUri(&req)
+	if err != nil {
+		helpers.AbortWithError(g, apperror.FromBinding(err))
+
+		return
+	}
+
+	data, err := controller.importRepo.GetById(id, req.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		helpers.AbortWithError(g, apperror.ErrImportNotFound)
+
+		return
+	}
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	res.ID = data.ID
+	res.Status = data.Status
+	res.Total = data.Total
+	res.Processed = data.Processed
+	res.Imported = data.Imported
+	res.Skipped = data.Skipped
+	res.Rejected = data.Rejected
+	locale := i18n.FromContext(g)
+	res.ErrorCode = data.Error
+	res.Error = localizeCode(locale, data.Error)
+	res.CompletedAt = data.CompletedAt
+	res.CreatedAt = *data.CreatedAt
+	if data.Results != "" {
+		json.Unmarshal([]byte(data.Results), &res.Results)
+	}
+	for i := range res.Results {
+		res.Results[i].Reason = localizeCode(locale, res.Results[i].Code)
+	}
+
+	response := helpers.NewSuccessResponse(res)
+	g.JSON(http.StatusOK, response)
+}
+
+// localizeCode turns an error code stored by the import worker into a message.
+// Imports stored before codes were used keep their text as it is.
+func localizeCode(locale, code string) string {
+	if code == "" {
+		return ""
+	}
+
+	return i18n.Error(locale, &apperror.Error{Code: code, Message: code})
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"rakamin/app"
+	"rakamin/apperror"
+	"rakamin/helpers"
+	"rakamin/middlewares"
+	"rakamin/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SessionController struct {
+	sessionRepo    models.SessionRepository
+	auditLogRepo   models.AuditLogRepository
+	AuthMiddleware *middlewares.AuthorizationMiddleware
+}
+
+func NewSessionController(sessionRepo models.SessionRepository, auditLogRepo models.AuditLogRepository, authMiddleware *middlewares.AuthorizationMiddleware) *SessionController {
+	return &SessionController{
+		sessionRepo:    sessionRepo,
+		auditLogRepo:   auditLogRepo,
+		AuthMiddleware: authMiddleware,
+	}
+}
+
+func (controller *SessionController) GetSessions(g *gin.Context) {
+	var (
+		err       error
+		id        int
+		currentId string
+		session   app.Sessions
+		res       app.GetAllSessionResponse
+	)
+
+	id, err = controller.AuthMiddleware.GetUserId(g)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	currentId, err = controller.AuthMiddleware.GetSessionId(g)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	data, err := controller.sessionRepo.GetAllByUserId(id)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	res.Sessions = []app.Sessions{}
+	for _, value := range data {
+		session.ID = value.ID
+		session.UserAgent = value.UserAgent
+		session.IP = value.IP
+		session.CreatedAt = *value.CreatedAt
+		session.LastSeenAt = *value.LastSeenAt
+		session.Current = value.ID == currentId
+		res.Sessions = append(res.Sessions, session)
+	}
+
+	response := helpers.NewSuccessResponse(res)
+	g.JSON(http.StatusOK, response)
+}
+
+func (controller *SessionController) RevokeSessionById(g *gin.Context) {
+	var (
+		err error
+		id  int
+		req app.RevokeSessionByIdRequest
+	)
+
+	id, err = controller.AuthMiddleware.GetUserId(g)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	err = g.ShouldBindUri(&req)
+	if err != nil {
+		helpers.AbortWithError(g, apperror.FromBinding(err))
+
+		return
+	}
+
+	err = controller.sessionRepo.RevokeById(id, req.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		helpers.AbortWithError(g, apperror.ErrSessionNotFound)
+
+		return
+	}
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	recordAudit(controller.auditLogRepo, g, id, "session.revoke", "session", req.ID, nil)
+
+	response := helpers.NewSuccessResponse(nil)
+	g.JSON(http.StatusOK, response)
+}
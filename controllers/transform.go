@@ -0,0 +1,249 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"rakamin/app"
+	"rakamin/apperror"
+	"rakamin/helpers"
+	"rakamin/metrics"
+	"rakamin/middlewares"
+	"rakamin/models"
+	"rakamin/tracing"
+	"rakamin/transform"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	transformPath   = "/img/%d"
+	transformMaxAge = 86400
+)
+
+type TransformController struct {
+	photoRepo      models.PhotoRepository
+	signer         *transform.Signer
+	cache          *transform.Cache
+	webpConverter  string
+	urlTTL         time.Duration
+	maxDimension   int
+	slots          chan struct{}
+	AuthMiddleware *middlewares.AuthorizationMiddleware
+}
+
+func NewTransformController(photoRepo models.PhotoRepository, signer *transform.Signer, cache *transform.Cache, webpConverter string, urlTTL time.Duration, maxDimension, concurrency int, authMiddleware *middlewares.AuthorizationMiddleware) *TransformController {
+	return &TransformController{
+		photoRepo:      photoRepo,
+		signer:         signer,
+		cache:          cache,
+		webpConverter:  webpConverter,
+		urlTTL:         urlTTL,
+		maxDimension:   maxDimension,
+		slots:          make(chan struct{}, concurrency),
+		AuthMiddleware: authMiddleware,
+	}
+}
+
+// SignURL hands the owner of a photo a signed transform URL. Signing only
+// happens here, so every cached variant was asked for by a logged in owner.
+func (controller *TransformController) SignURL(g *gin.Context) {
+	var (
+		err error
+		id  int
+		req app.TransformRequest
+	)
+
+	id, err = controller.AuthMiddleware.GetUserId(g)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	photoId, err := strconv.Atoi(g.Param("photoId"))
+	if err != nil {
+		helpers.AbortWithError(g, apperror.ErrInvalidPhotoId)
+
+		return
+	}
+
+	err = g.ShouldBindQuery(&req)
+	if err != nil {
+		helpers.AbortWithError(g, apperror.FromBinding(err))
+
+		return
+	}
+
+	params := transformParams(req)
+	if !params.Validate(controller.maxDimension) {
+		helpers.AbortWithError(g, apperror.ErrInvalidTransform)
+
+		return
+	}
+
+	_, err = controller.photoRepo.WithContext(g.Request.Context()).GetById(id, photoId)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	expiresAt := time.Now().Add(controller.urlTTL).Truncate(time.Second)
+	response := helpers.NewSuccessResponse(app.TransformURLResponse{
+		URL:       controller.signer.URL(fmt.Sprintf(transformPath, photoId), photoId, params, expiresAt),
+		ExpiresAt: expiresAt,
+	})
+	g.JSON(http.StatusOK, response)
+}
+
+func (controller *TransformController) Serve(g *gin.Context) {
+	var req app.TransformRequest
+
+	photoId, err := strconv.Atoi(g.Param("photoId"))
+	if err != nil {
+		helpers.AbortWithError(g, apperror.ErrInvalidPhotoId)
+
+		return
+	}
+
+	err = g.ShouldBindQuery(&req)
+	if err != nil {
+		helpers.AbortWithError(g, apperror.FromBinding(err))
+
+		return
+	}
+
+	params := transformParams(req)
+	if !controller.signer.Verify(photoId, params, req.Expires, req.Signature) {
+		helpers.AbortWithError(g, apperror.ErrInvalidSignature)
+
+		return
+	}
+	expiresIn := time.Until(time.Unix(req.Expires, 0))
+	if expiresIn <= 0 {
+		helpers.AbortWithError(g, apperror.ErrSignatureExpired)
+
+		return
+	}
+	if !params.Validate(controller.maxDimension) {
+		helpers.AbortWithError(g, apperror.ErrInvalidTransform)
+
+		return
+	}
+
+	photo, err := controller.photoRepo.WithContext(g.Request.Context()).GetAnyById(photoId)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+	if photo.Status != models.PhotoStatusReady {
+		helpers.AbortWithError(g, apperror.ErrPhotoNotFound)
+
+		return
+	}
+
+	sourceFormat := transform.SourceFormat(mime.TypeByExtension(filepath.Ext(photo.PhotoURL)))
+	if sourceFormat == "" {
+		helpers.AbortWithError(g, apperror.ErrCannotTransform)
+
+		return
+	}
+	format := params.Format
+	if format == "" {
+		format = sourceFormat
+		if format == transform.FormatWebP && controller.webpConverter == "" {
+			format = transform.FormatPNG
+		}
+	}
+	if format == transform.FormatWebP && controller.webpConverter == "" {
+		helpers.AbortWithError(g, apperror.ErrCannotTransform)
+
+		return
+	}
+
+	key := params.Key(photoId, photo.PhotoURL+"|"+photo.Hash, format)
+	file, ok := controller.cache.Get(key)
+	if ok {
+		metrics.ImageTransforms.WithLabelValues("hit").Inc()
+	} else {
+		metrics.ImageTransforms.WithLabelValues("miss").Inc()
+
+		_, span := tracing.Start(g.Request.Context(), "image.transform", attribute.String("image.format", format))
+		file, err = controller.render(g, photo.PhotoURL, key, params, format)
+		tracing.End(span, err)
+		if err != nil {
+			helpers.AbortWithError(g, err)
+
+			return
+		}
+	}
+	defer file.Close()
+
+	// The URL stays the same when the photo is replaced, so unlike the image
+	// dir this can't be immutable, and it must not outlive the link. The key
+	// covers the source and params, and makes the ETag.
+	maxAge := int(expiresIn / time.Second)
+	if maxAge > transformMaxAge {
+		maxAge = transformMaxAge
+	}
+	name := fmt.Sprintf("photo-%d%s", photoId, filepath.Ext(key))
+	serveContent(g, file, name, strongETag(strings.TrimSuffix(key, filepath.Ext(key))), fmt.Sprintf("public, max-age=%d", maxAge))
+}
+
+func (controller *TransformController) render(g *gin.Context, source, key string, params transform.Params, format string) (result *os.File, err error) {
+	select {
+	case controller.slots <- struct{}{}:
+		defer func() { <-controller.slots }()
+	case <-g.Request.Context().Done():
+		err = g.Request.Context().Err()
+		return
+	}
+
+	file, err := os.Open(source)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		err = apperror.ErrCannotTransform.Wrap(err)
+		return
+	}
+
+	var data []byte
+	if format == transform.FormatWebP {
+		data, err = transform.EncodeWebP(g.Request.Context(), controller.webpConverter, transform.Apply(img, params), params.Quality)
+	} else {
+		var buf bytes.Buffer
+		err = transform.Encode(&buf, transform.Apply(img, params), format, params.Quality)
+		data = buf.Bytes()
+	}
+	if err != nil {
+		return
+	}
+
+	result, err = controller.cache.Put(key, data)
+
+	return
+}
+
+func transformParams(req app.TransformRequest) transform.Params {
+	return transform.Params{
+		Width:   req.Width,
+		Height:  req.Height,
+		Fit:     req.Fit,
+		Quality: req.Quality,
+		Format:  req.Format,
+	}
+}
@@ -4,22 +4,41 @@ import (
 	"errors"
 	"net/http"
 	"rakamin/app"
+	"rakamin/apperror"
+	"rakamin/events"
 	"rakamin/helpers"
+	"rakamin/i18n"
+	"rakamin/metrics"
 	"rakamin/middlewares"
 	"rakamin/models"
+	"rakamin/quota"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type UserController struct {
-	userRepo       models.UserRepository
-	AuthMiddleware *middlewares.AuthorizationMiddleware
+	userRepo         models.UserRepository
+	sessionRepo      models.SessionRepository
+	notificationRepo models.NotificationRepository
+	auditLogRepo     models.AuditLogRepository
+	quotaChecker     *quota.Checker
+	publisher        events.Publisher
+	AuthMiddleware   *middlewares.AuthorizationMiddleware
+	gracePeriod      time.Duration
 }
 
-func NewUserController(userRepo models.UserRepository, authMiddleware *middlewares.AuthorizationMiddleware) *UserController {
+func NewUserController(userRepo models.UserRepository, sessionRepo models.SessionRepository, notificationRepo models.NotificationRepository, auditLogRepo models.AuditLogRepository, quotaChecker *quota.Checker, publisher events.Publisher, authMiddleware *middlewares.AuthorizationMiddleware, gracePeriod time.Duration) *UserController {
 	return &UserController{
-		userRepo:       userRepo,
-		AuthMiddleware: authMiddleware,
+		userRepo:         userRepo,
+		sessionRepo:      sessionRepo,
+		notificationRepo: notificationRepo,
+		auditLogRepo:     auditLogRepo,
+		quotaChecker:     quotaChecker,
+		publisher:        publisher,
+		AuthMiddleware:   authMiddleware,
+		gracePeriod:      gracePeriod,
 	}
 }
 
@@ -31,25 +50,32 @@ func (controller *UserController) Register(g *gin.Context) {
 
 	err = g.ShouldBind(&request)
 	if err != nil {
-		response := helpers.NewErrorResponse(err)
-		g.JSON(http.StatusBadRequest, response)
+		helpers.AbortWithError(g, apperror.FromBinding(err))
 
 		return
 	}
 
-	err = controller.userRepo.Register(models.User{
+	err = controller.userRepo.WithContext(g.Request.Context()).Register(models.User{
 		Username: request.Username,
 		Email:    request.Email,
 		Password: request.Password,
 	})
 
 	if err != nil {
-		response := helpers.NewErrorResponse(err)
-		g.JSON(http.StatusInternalServerError, response)
+		helpers.AbortWithError(g, err)
 
 		return
 	}
 
+	user, err := controller.userRepo.WithContext(g.Request.Context()).GetByEmail(request.Email)
+	if err == nil {
+		controller.publisher.Publish(events.New(events.UserRegistered, user.ID, app.UserEvent{
+			ID:       user.ID,
+			Username: user.Username,
+			Email:    user.Email,
+		}))
+	}
+
 	response := helpers.NewSuccessInsertResponse(nil)
 	g.JSON(http.StatusCreated, response)
 }
@@ -63,30 +89,91 @@ func (controller *UserController) Login(g *gin.Context) {
 
 	err = g.ShouldBind(&request)
 	if err != nil {
-		response := helpers.NewErrorResponse(err)
-		g.JSON(http.StatusBadRequest, response)
+		helpers.AbortWithError(g, apperror.FromBinding(err))
 
 		return
 	}
 
-	data, err := controller.userRepo.GetByEmail(request.Email)
+	data, err := controller.userRepo.WithContext(g.Request.Context()).GetByEmail(request.Email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		data, err = controller.userRepo.WithContext(g.Request.Context()).GetDeletedByEmail(request.Email)
+		if err == nil && time.Since(data.DeletedAt.Time) > controller.gracePeriod {
+			err = gorm.ErrRecordNotFound
+		}
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		metrics.LoginAttempts.WithLabelValues("failure").Inc()
+		err = apperror.ErrInvalidCredentials
+	}
 	if err != nil {
-		response := helpers.NewErrorResponse(err)
-		g.JSON(http.StatusInternalServerError, response)
+		helpers.AbortWithError(g, err)
 
 		return
 	}
 
 	if !helpers.ValidateHash(request.Password, data.Password) {
+		metrics.LoginAttempts.WithLabelValues("failure").Inc()
+		helpers.AbortWithError(g, apperror.ErrInvalidCredentials)
+
+		return
+	}
+
+	if data.DisabledAt != nil {
+		metrics.LoginAttempts.WithLabelValues("failure").Inc()
+		helpers.AbortWithError(g, apperror.ErrAccountDisabled)
+
+		return
+	}
+
+	if data.DeletedAt.Valid {
+		err = controller.userRepo.WithContext(g.Request.Context()).RestoreById(data.ID)
 		if err != nil {
-			response := helpers.NewErrorResponse(errors.New("wrong email and password"))
-			g.JSON(http.StatusUnauthorized, response)
+			helpers.AbortWithError(g, err)
 
 			return
 		}
+
+		recordAudit(controller.auditLogRepo, g, data.ID, "user.restore", "user", data.ID, nil)
+		controller.notificationRepo.Insert(models.Notification{
+			UserID:  data.ID,
+			Title:   i18n.T(data.Locale, "notification.account_restored.title"),
+			Message: i18n.T(data.Locale, "notification.account_restored.message"),
+		})
+	}
+
+	userAgent := g.Request.UserAgent()
+	knownDevice, err := controller.sessionRepo.HasUserAgent(data.ID, userAgent)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
 	}
 
-	token := controller.AuthMiddleware.GenerateToken(data.ID)
+	session := models.Session{
+		ID:        helpers.GetUUID(),
+		UserID:    data.ID,
+		UserAgent: userAgent,
+		IP:        g.ClientIP(),
+	}
+	err = controller.sessionRepo.Create(session)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	if !knownDevice {
+		controller.notificationRepo.Insert(models.Notification{
+			UserID:  data.ID,
+			Title:   i18n.T(data.Locale, "notification.new_login.title"),
+			Message: i18n.T(data.Locale, "notification.new_login.message", session.UserAgent, session.IP),
+		})
+	}
+
+	recordAudit(controller.auditLogRepo, g, data.ID, "user.login", "session", session.ID, nil)
+
+	metrics.LoginAttempts.WithLabelValues("success").Inc()
+	token := controller.AuthMiddleware.GenerateToken(data.ID, session.ID)
 
 	response.Token = token
 	res := helpers.NewSuccessResponse(response)
@@ -102,19 +189,36 @@ func (controller *UserController) GetUserById(g *gin.Context) {
 
 	id, err = controller.AuthMiddleware.GetUserId(g)
 	if err != nil {
+		helpers.AbortWithError(g, err)
+
 		return
 	}
 
-	data, err := controller.userRepo.GetById(id)
+	data, err := controller.userRepo.WithContext(g.Request.Context()).GetById(id)
 	if err != nil {
-		response := helpers.NewErrorResponse(err)
-		g.JSON(http.StatusInternalServerError, response)
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	usage, err := controller.quotaChecker.Usage(g.Request.Context(), id)
+	if err != nil {
+		helpers.AbortWithError(g, err)
 
 		return
 	}
 
 	res.Username = data.Username
 	res.Email = data.Email
+	res.Locale = i18n.Preferred(data.Locale)
+	res.Storage = app.StorageUsage{
+		Plan:         usage.Plan,
+		UsedBytes:    usage.UsedBytes,
+		MaxBytes:     usage.Limits.MaxBytes,
+		Photos:       usage.Photos,
+		MaxPhotos:    usage.Limits.MaxPhotos,
+		MaxFileBytes: usage.Limits.MaxFileSize,
+	}
 	res.CreatedAt = *data.CreatedAt
 
 	response := helpers.NewSuccessResponse(res)
@@ -130,29 +234,52 @@ func (controller *UserController) UpdateUserById(g *gin.Context) {
 
 	id, err = controller.AuthMiddleware.GetUserId(g)
 	if err != nil {
+		helpers.AbortWithError(g, err)
+
 		return
 	}
 
 	err = g.ShouldBind(&req)
 	if err != nil {
-		response := helpers.NewErrorResponse(err)
-		g.JSON(http.StatusBadRequest, response)
+		helpers.AbortWithError(g, apperror.FromBinding(err))
 
 		return
 	}
 
-	err = controller.userRepo.UpdateById(id, models.User{
+	before, err := controller.userRepo.WithContext(g.Request.Context()).GetById(id)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	err = controller.userRepo.WithContext(g.Request.Context()).UpdateById(id, models.User{
 		Username: req.Username,
 		Email:    req.Email,
 		Password: req.Password,
+		Locale:   req.Locale,
 	})
 	if err != nil {
-		response := helpers.NewErrorResponse(err)
-		g.JSON(http.StatusInternalServerError, response)
+		helpers.AbortWithError(g, err)
 
 		return
 	}
 
+	changes := map[string]app.AuditChange{}
+	if req.Username != "" && req.Username != before.Username {
+		changes["username"] = app.AuditChange{Before: before.Username, After: req.Username}
+	}
+	if req.Email != "" && req.Email != before.Email {
+		changes["email"] = app.AuditChange{Before: before.Email, After: req.Email}
+	}
+	if req.Locale != "" && req.Locale != before.Locale {
+		changes["locale"] = app.AuditChange{Before: before.Locale, After: req.Locale}
+	}
+	if req.Password != "" {
+		changes["password"] = app.AuditChange{Before: "[redacted]", After: "[redacted]"}
+	}
+	recordAudit(controller.auditLogRepo, g, id, "user.update", "user", id, changes)
+
 	response := helpers.NewSuccessResponse(nil)
 	g.JSON(http.StatusOK, response)
 }
@@ -165,17 +292,42 @@ func (controller *UserController) DeleteUserById(g *gin.Context) {
 
 	id, err = controller.AuthMiddleware.GetUserId(g)
 	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	before, err := controller.userRepo.WithContext(g.Request.Context()).GetById(id)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	err = controller.userRepo.WithContext(g.Request.Context()).DeleteById(id)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
 		return
 	}
 
-	err = controller.userRepo.DeleteById(id)
+	err = controller.sessionRepo.RevokeAllByUserId(id)
 	if err != nil {
-		response := helpers.NewErrorResponse(err)
-		g.JSON(http.StatusInternalServerError, response)
+		helpers.AbortWithError(g, err)
 
 		return
 	}
 
+	recordAudit(controller.auditLogRepo, g, id, "user.delete", "user", id, map[string]app.AuditChange{
+		"username": {Before: before.Username, After: nil},
+		"email":    {Before: before.Email, After: nil},
+	})
+	controller.publisher.Publish(events.New(events.UserDeleted, id, app.UserEvent{
+		ID:       before.ID,
+		Username: before.Username,
+		Email:    before.Email,
+	}))
+
 	response := helpers.NewSuccessResponse(nil)
 	g.JSON(http.StatusOK, response)
 }
@@ -0,0 +1,308 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"rakamin/app"
+	"rakamin/apperror"
+	"rakamin/events"
+	"rakamin/helpers"
+	"rakamin/middlewares"
+	"rakamin/models"
+	"rakamin/workers"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const webhookDeliveryLimit = 100
+
+type WebhookController struct {
+	webhookRepo       models.WebhookRepository
+	auditLogRepo      models.AuditLogRepository
+	webhookDispatcher *workers.WebhookDispatcher
+	AuthMiddleware    *middlewares.AuthorizationMiddleware
+}
+
+func NewWebhookController(webhookRepo models.WebhookRepository, auditLogRepo models.AuditLogRepository, webhookDispatcher *workers.WebhookDispatcher, authMiddleware *middlewares.AuthorizationMiddleware) *WebhookController {
+	return &WebhookController{
+		webhookRepo:       webhookRepo,
+		auditLogRepo:      auditLogRepo,
+		webhookDispatcher: webhookDispatcher,
+		AuthMiddleware:    authMiddleware,
+	}
+}
+
+func (controller *WebhookController) Create(g *gin.Context) {
+	controller.create(g, false)
+}
+
+func (controller *WebhookController) CreateGlobal(g *gin.Context) {
+	controller.create(g, true)
+}
+
+func (controller *WebhookController) create(g *gin.Context, global bool) {
+	var (
+		err error
+		id  int
+		req app.CreateWebhookRequest
+	)
+
+	id, err = controller.AuthMiddleware.GetUserId(g)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	err = g.ShouldBind(&req)
+	if err != nil {
+		helpers.AbortWithError(g, apperror.FromBinding(err))
+
+		return
+	}
+
+	if !strings.HasPrefix(req.URL, "http://") && !strings.HasPrefix(req.URL, "https://") {
+		helpers.AbortWithError(g, apperror.ErrWebhookInvalidURL)
+
+		return
+	}
+
+	for _, eventType := range req.Events {
+		if eventType != "*" && !events.IsValidType(eventType) {
+			helpers.AbortWithError(g, apperror.ErrWebhookInvalidEvent)
+
+			return
+		}
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		secret, err = helpers.GetRandomToken(32)
+		if err != nil {
+			helpers.AbortWithError(g, err)
+
+			return
+		}
+	}
+
+	webhookId, err := controller.webhookRepo.Insert(models.Webhook{
+		UserID: id,
+		URL:    req.URL,
+		Secret: secret,
+		Events: strings.Join(req.Events, ","),
+		Global: global,
+		Active: true,
+	})
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	recordAudit(controller.auditLogRepo, g, id, "webhook.create", "webhook", webhookId, nil)
+
+	response := helpers.NewSuccessInsertResponse(app.CreateWebhookResponse{
+		ID:     webhookId,
+		Secret: secret,
+	})
+	g.JSON(http.StatusCreated, response)
+}
+
+func (controller *WebhookController) GetWebhooks(g *gin.Context) {
+	var (
+		err     error
+		id      int
+		webhook app.Webhooks
+		res     app.GetAllWebhookResponse
+	)
+
+	id, err = controller.AuthMiddleware.GetUserId(g)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	data, err := controller.webhookRepo.GetAllByUserId(id)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	res.Webhooks = []app.Webhooks{}
+	for _, value := range data {
+		webhook.ID = value.ID
+		webhook.URL = value.URL
+		webhook.Events = strings.Split(value.Events, ",")
+		webhook.Global = value.Global
+		webhook.Active = value.Active
+		webhook.CreatedAt = *value.CreatedAt
+		res.Webhooks = append(res.Webhooks, webhook)
+	}
+
+	response := helpers.NewSuccessResponse(res)
+	g.JSON(http.StatusOK, response)
+}
+
+func (controller *WebhookController) DeleteWebhookById(g *gin.Context) {
+	var (
+		err error
+		id  int
+		req app.WebhookByIdRequest
+	)
+
+	id, err = controller.AuthMiddleware.GetUserId(g)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	err = g.ShouldBindUri(&req)
+	if err != nil {
+		helpers.AbortWithError(g, apperror.FromBinding(err))
+
+		return
+	}
+
+	err = controller.webhookRepo.DeleteById(id, req.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		helpers.AbortWithError(g, apperror.ErrWebhookNotFound)
+
+		return
+	}
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	recordAudit(controller.auditLogRepo, g, id, "webhook.delete", "webhook", req.ID, nil)
+
+	response := helpers.NewSuccessResponse(nil)
+	g.JSON(http.StatusOK, response)
+}
+
+func (controller *WebhookController) Ping(g *gin.Context) {
+	webhook, ok := controller.ownedWebhook(g)
+	if !ok {
+		return
+	}
+
+	err := controller.webhookDispatcher.Enqueue(webhook, events.New(events.Ping, webhook.UserID, gin.H{"webhookId": webhook.ID}))
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	response := helpers.NewSuccessResponse(nil)
+	g.JSON(http.StatusAccepted, response)
+}
+
+func (controller *WebhookController) GetDeliveries(g *gin.Context) {
+	var (
+		delivery app.WebhookDeliveries
+		res      app.GetAllWebhookDeliveryResponse
+	)
+
+	webhook, ok := controller.ownedWebhook(g)
+	if !ok {
+		return
+	}
+
+	data, err := controller.webhookRepo.GetAllDeliveriesByWebhookId(webhook.ID, webhookDeliveryLimit)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	res.Deliveries = []app.WebhookDeliveries{}
+	for _, value := range data {
+		delivery.ID = value.ID
+		delivery.EventID = value.EventID
+		delivery.EventType = value.EventType
+		delivery.Attempt = value.Attempt
+		delivery.StatusCode = value.StatusCode
+		delivery.ResponseBody = value.ResponseBody
+		delivery.Error = value.Error
+		delivery.DurationMs = value.DurationMs
+		delivery.Success = value.Success
+		delivery.CreatedAt = *value.CreatedAt
+		res.Deliveries = append(res.Deliveries, delivery)
+	}
+
+	response := helpers.NewSuccessResponse(res)
+	g.JSON(http.StatusOK, response)
+}
+
+func (controller *WebhookController) Redeliver(g *gin.Context) {
+	var req app.WebhookDeliveryByIdRequest
+
+	webhook, ok := controller.ownedWebhook(g)
+	if !ok {
+		return
+	}
+
+	err := g.ShouldBindUri(&req)
+	if err != nil {
+		helpers.AbortWithError(g, apperror.FromBinding(err))
+
+		return
+	}
+
+	delivery, err := controller.webhookRepo.GetDeliveryById(webhook.ID, req.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		helpers.AbortWithError(g, apperror.ErrDeliveryNotFound)
+
+		return
+	}
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	err = controller.webhookDispatcher.Redeliver(delivery)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	response := helpers.NewSuccessResponse(nil)
+	g.JSON(http.StatusAccepted, response)
+}
+
+func (controller *WebhookController) ownedWebhook(g *gin.Context) (webhook models.Webhook, ok bool) {
+	var req app.WebhookByIdRequest
+
+	id, err := controller.AuthMiddleware.GetUserId(g)
+	if err != nil {
+		helpers.AbortWithError(g, err)
+
+		return
+	}
+
+	err = g.ShouldBindUri(&req)
+	if err != nil {
+		helpers.AbortWithError(g, apperror.FromBinding(err))
+
+		return
+	}
+
+	webhook, err = controller.webhookRepo.GetById(req.ID)
+	if err != nil || webhook.UserID != id {
+		helpers.AbortWithError(g, apperror.ErrWebhookNotFound)
+
+		return
+	}
+
+	ok = true
+
+	return
+}
@@ -1,24 +1,25 @@
 package database
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"rakamin/models"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
-
 type ConfigDB struct {
 	Username string
 	Password string
 	Host     string
 	Port     string
 	Database string
+	Logger   logger.Interface
 }
 
-func (config *ConfigDB) ConfigDB() *gorm.DB {
+func (config *ConfigDB) ConfigDB() (*gorm.DB, error) {
 	dsn := fmt.Sprintf("%v:%v@tcp(%v:%v)/%v?charset=utf8mb4&parseTime=True&loc=Local",
 		config.Username,
 		config.Password,
@@ -26,19 +27,46 @@ func (config *ConfigDB) ConfigDB() *gorm.DB {
 		config.Port,
 		config.Database)
 
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
-	if err != nil {
-		log.Fatal(err)
-	}
+	return gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: config.Logger})
+}
+
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(Models()...)
+}
 
-	err = db.Debug().AutoMigrate(
+func Models() []interface{} {
+	return []interface{}{
 		&models.User{},
 		&models.Photo{},
-	)
+		&models.Session{},
+		&models.Notification{},
+		&models.AuditLog{},
+		&models.DataExport{},
+		&models.PhotoImport{},
+		&models.Job{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.StreamEvent{},
+		&models.RateLimitBucket{},
+	}
+}
 
+func Ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+
+	return sqlDB.PingContext(ctx)
+}
+
+func CheckMigrations(db *gorm.DB) error {
+	migrator := db.Migrator()
+	for _, model := range Models() {
+		if !migrator.HasTable(model) {
+			return fmt.Errorf("table for %T is missing", model)
+		}
 	}
 
-	return db
+	return nil
 }
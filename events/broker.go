@@ -0,0 +1,124 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"rakamin/logger"
+	"rakamin/models"
+	"sync"
+	"time"
+)
+
+type Broker interface {
+	Publish(event Event) error
+	Subscribe(ctx context.Context, wg *sync.WaitGroup, deliver func(Event))
+}
+
+type LocalBroker struct {
+	mu          sync.RWMutex
+	subscribers []func(Event)
+}
+
+func NewLocalBroker() *LocalBroker {
+	return &LocalBroker{}
+}
+
+func (b *LocalBroker) Publish(event Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, deliver := range b.subscribers {
+		deliver(event)
+	}
+
+	return nil
+}
+
+func (b *LocalBroker) Subscribe(ctx context.Context, wg *sync.WaitGroup, deliver func(Event)) {
+	b.mu.Lock()
+	b.subscribers = append(b.subscribers, deliver)
+	b.mu.Unlock()
+}
+
+type DatabaseBroker struct {
+	streamEventRepo models.StreamEventRepository
+	PollInterval    time.Duration
+	Retention       time.Duration
+}
+
+func NewDatabaseBroker(streamEventRepo models.StreamEventRepository, pollInterval time.Duration) *DatabaseBroker {
+	return &DatabaseBroker{
+		streamEventRepo: streamEventRepo,
+		PollInterval:    pollInterval,
+		Retention:       time.Hour,
+	}
+}
+
+func (b *DatabaseBroker) Publish(event Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+
+	return b.streamEventRepo.Insert(models.StreamEvent{
+		EventID:   event.ID,
+		Type:      event.Type,
+		UserID:    event.UserID,
+		Data:      string(data),
+		CreatedAt: &event.CreatedAt,
+	})
+}
+
+func (b *DatabaseBroker) Subscribe(ctx context.Context, wg *sync.WaitGroup, deliver func(Event)) {
+	lastId, err := b.streamEventRepo.GetLastId()
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("reading last stream event")
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(b.PollInterval)
+		defer ticker.Stop()
+
+		cleanup := time.NewTicker(b.Retention)
+		defer cleanup.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-cleanup.C:
+				b.streamEventRepo.DeleteOlderThan(time.Now().Add(-b.Retention))
+			case <-ticker.C:
+				lastId = b.poll(lastId, deliver)
+			}
+		}
+	}()
+}
+
+func (b *DatabaseBroker) poll(lastId int, deliver func(Event)) int {
+	rows, err := b.streamEventRepo.GetAllAfterId(lastId, 500)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("polling stream events")
+		return lastId
+	}
+
+	for _, row := range rows {
+		event := Event{
+			ID:     row.EventID,
+			Type:   row.Type,
+			UserID: row.UserID,
+			Data:   json.RawMessage(row.Data),
+		}
+		if row.CreatedAt != nil {
+			event.CreatedAt = *row.CreatedAt
+		}
+
+		deliver(event)
+		lastId = row.ID
+	}
+
+	return lastId
+}
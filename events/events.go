@@ -0,0 +1,65 @@
+package events
+
+import (
+	"rakamin/helpers"
+	"time"
+)
+
+const (
+	PhotoCreated   = "photo.created"
+	PhotoUpdated   = "photo.updated"
+	PhotoDeleted   = "photo.deleted"
+	PhotoProcessed = "photo.processed"
+	UserRegistered = "user.registered"
+	UserDeleted    = "user.deleted"
+	Ping           = "ping"
+)
+
+var Types = []string{
+	PhotoCreated,
+	PhotoUpdated,
+	PhotoDeleted,
+	PhotoProcessed,
+	UserRegistered,
+	UserDeleted,
+}
+
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	UserID    int         `json:"userId"`
+	Data      interface{} `json:"data"`
+	CreatedAt time.Time   `json:"createdAt"`
+}
+
+type Publisher interface {
+	Publish(event Event)
+}
+
+type MultiPublisher []Publisher
+
+func New(eventType string, userId int, data interface{}) Event {
+	return Event{
+		ID:        helpers.GetUUID(),
+		Type:      eventType,
+		UserID:    userId,
+		Data:      data,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+func IsValidType(eventType string) bool {
+	for _, value := range Types {
+		if value == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (publishers MultiPublisher) Publish(event Event) {
+	for _, publisher := range publishers {
+		publisher.Publish(event)
+	}
+}
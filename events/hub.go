@@ -0,0 +1,164 @@
+package events
+
+import (
+	"context"
+	"rakamin/logger"
+	"sync"
+	"time"
+)
+
+const backlogSweepInterval = time.Minute
+
+type Hub struct {
+	broker       Broker
+	BacklogSize  int
+	ReplayWindow time.Duration
+	mu           sync.Mutex
+	subscribers  map[int]map[chan Event]struct{}
+	backlog      map[int][]Event
+}
+
+func NewHub(broker Broker, backlogSize int, replayWindow time.Duration) *Hub {
+	return &Hub{
+		broker:       broker,
+		BacklogSize:  backlogSize,
+		ReplayWindow: replayWindow,
+		subscribers:  map[int]map[chan Event]struct{}{},
+		backlog:      map[int][]Event{},
+	}
+}
+
+func (h *Hub) Start(ctx context.Context, wg *sync.WaitGroup) {
+	h.broker.Subscribe(ctx, wg, h.dispatch)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(backlogSweepInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				h.sweep(time.Now())
+			}
+		}
+	}()
+}
+
+func (h *Hub) Publish(event Event) {
+	err := h.broker.Publish(event)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("publishing event")
+	}
+}
+
+func (h *Hub) Subscribe(userId int, lastEventId string) (events <-chan Event, missed []Event, cancel func()) {
+	ch := make(chan Event, 32)
+
+	h.mu.Lock()
+	if h.subscribers[userId] == nil {
+		h.subscribers[userId] = map[chan Event]struct{}{}
+	}
+	h.subscribers[userId][ch] = struct{}{}
+	missed = h.since(userId, lastEventId)
+	h.mu.Unlock()
+
+	cancel = func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		if _, ok := h.subscribers[userId][ch]; ok {
+			delete(h.subscribers[userId], ch)
+			close(ch)
+		}
+		if len(h.subscribers[userId]) == 0 {
+			delete(h.subscribers, userId)
+		}
+	}
+
+	return ch, missed, cancel
+}
+
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for userId, subscribers := range h.subscribers {
+		for ch := range subscribers {
+			close(ch)
+		}
+		delete(h.subscribers, userId)
+	}
+}
+
+func (h *Hub) dispatch(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	backlog := append(h.expire(h.backlog[event.UserID], time.Now()), event)
+	if len(backlog) > h.BacklogSize {
+		backlog = backlog[len(backlog)-h.BacklogSize:]
+	}
+	h.backlog[event.UserID] = backlog
+
+	for ch := range h.subscribers[event.UserID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+func (h *Hub) since(userId int, lastEventId string) []Event {
+	if lastEventId == "" {
+		return nil
+	}
+
+	backlog := h.expire(h.backlog[userId], time.Now())
+	for index, event := range backlog {
+		if event.ID == lastEventId {
+			return append([]Event{}, backlog[index+1:]...)
+		}
+	}
+
+	return append([]Event{}, backlog...)
+}
+
+// sweep drops events too old to replay, so the backlog of users who never
+// reconnect doesn't pile up.
+func (h *Hub) sweep(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for userId, backlog := range h.backlog {
+		backlog = h.expire(backlog, now)
+		if len(backlog) == 0 {
+			delete(h.backlog, userId)
+			continue
+		}
+		h.backlog[userId] = backlog
+	}
+}
+
+// expire drops events older than the replay window. The backlog is in
+// publish order, so they are all at the front.
+func (h *Hub) expire(backlog []Event, now time.Time) []Event {
+	if h.ReplayWindow <= 0 {
+		return backlog
+	}
+
+	cutoff := now.Add(-h.ReplayWindow)
+	index := 0
+	for index < len(backlog) && backlog[index].CreatedAt.Before(cutoff) {
+		index++
+	}
+	if index == len(backlog) {
+		return nil
+	}
+
+	return backlog[index:]
+}
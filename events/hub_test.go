@@ -0,0 +1,56 @@
+package events
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestHubBacklogExpiry(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-2 * time.Hour)
+
+	tests := []struct {
+		name        string
+		published   []time.Time
+		lastEventId string
+		want        int
+	}{
+		{"all fresh", []time.Time{now, now, now}, "missing", 3},
+		{"old dropped", []time.Time{old, old, now}, "missing", 1},
+		{"all old", []time.Time{old, old}, "missing", 0},
+		{"after last seen", []time.Time{now, now, now}, "1", 1},
+		{"no last event id", []time.Time{now}, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewHub(NewLocalBroker(), 100, time.Hour)
+			for i, createdAt := range tt.published {
+				hub.dispatch(Event{ID: fmt.Sprint(i), UserID: 1, CreatedAt: createdAt})
+			}
+
+			_, missed, cancel := hub.Subscribe(1, tt.lastEventId)
+			defer cancel()
+			if len(missed) != tt.want {
+				t.Errorf("replayed %d events, want %d", len(missed), tt.want)
+			}
+		})
+	}
+}
+
+func TestHubSweepDropsStaleUsers(t *testing.T) {
+	hub := NewHub(NewLocalBroker(), 100, time.Hour)
+	hub.dispatch(Event{ID: "a", UserID: 1, CreatedAt: time.Now()})
+	hub.dispatch(Event{ID: "b", UserID: 2, CreatedAt: time.Now()})
+
+	hub.sweep(time.Now().Add(30 * time.Minute))
+	if len(hub.backlog) != 2 {
+		t.Fatalf("swept users inside the replay window, %d left", len(hub.backlog))
+	}
+
+	hub.sweep(time.Now().Add(2 * time.Hour))
+	if len(hub.backlog) != 0 {
+		t.Fatalf("kept %d users past the replay window", len(hub.backlog))
+	}
+}
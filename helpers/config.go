@@ -1,12 +1,36 @@
 package helpers
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"rakamin/i18n"
+	"rakamin/logger"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
+const (
+	EnvPrefix        = "APP"
+	DefaultJWTSecret = "secretRakamin"
+	minJWTSecretLen  = 32
+)
+
 type Config struct {
+	Env    string `json:"env"`
+	Server struct {
+		Address           string        `json:"address"`
+		ReadTimeout       time.Duration `json:"readTimeout"`
+		ReadHeaderTimeout time.Duration `json:"readHeaderTimeout"`
+		WriteTimeout      time.Duration `json:"writeTimeout"`
+		IdleTimeout       time.Duration `json:"idleTimeout"`
+		ShutdownTimeout   time.Duration `json:"shutdownTimeout"`
+		DrainDelay        time.Duration `json:"drainDelay"`
+	} `json:"server"`
 	Mysql struct {
 		Host string `json:"host"`
 		Port string `json:"port"`
@@ -15,25 +39,393 @@ type Config struct {
 		Name string `json:"name"`
 	} `json:"mysql"`
 	JWT struct {
-		Secret  string `json:"secret"`
-		Expired int    `json:"expired"`
+		Secret string        `json:"secret"`
+		Expiry time.Duration `json:"expiry"`
 	} `json:"jwt"`
+	Audit struct {
+		RetentionDays int `json:"retentionDays"`
+	} `json:"audit"`
+	Trash struct {
+		GraceDays int `json:"graceDays"`
+	} `json:"trash"`
+	Export struct {
+		Dir     string        `json:"dir"`
+		LinkTTL time.Duration `json:"linkTTL"`
+	} `json:"export"`
+	Uploads struct {
+		AllowedTypes      []string `json:"allowedTypes"`
+		MaxRequestSizeMB  int64    `json:"maxRequestSizeMB"`
+		MultipartMemoryMB int64    `json:"multipartMemoryMB"`
+		MaxWidth          int      `json:"maxWidth"`
+		MaxHeight         int      `json:"maxHeight"`
+		MaxMegapixels     float64  `json:"maxMegapixels"`
+		MaxConcurrent     int      `json:"maxConcurrent"`
+	} `json:"uploads"`
+	Images struct {
+		HEICConverter        string        `json:"heicConverter"`
+		WebPConverter        string        `json:"webpConverter"`
+		WebPQuality          int           `json:"webpQuality"`
+		SigningKey           string        `json:"signingKey"`
+		LinkTTL              time.Duration `json:"linkTTL"`
+		CacheDir             string        `json:"cacheDir"`
+		CacheSizeMB          int64         `json:"cacheSizeMB"`
+		MaxDimension         int           `json:"maxDimension"`
+		TransformConcurrency int           `json:"transformConcurrency"`
+	} `json:"images"`
+	Quotas map[string]QuotaPlan `json:"quotas"`
+	Import struct {
+		Dir              string `json:"dir"`
+		MaxFileSizeMB    int    `json:"maxFileSizeMB"`
+		MaxEntries       int    `json:"maxEntries"`
+		MaxArchiveSizeMB int64  `json:"maxArchiveSizeMB"`
+	} `json:"import"`
+	Jobs struct {
+		Concurrency  int           `json:"concurrency"`
+		MaxAttempts  int           `json:"maxAttempts"`
+		Backoff      time.Duration `json:"backoff"`
+		PollInterval time.Duration `json:"pollInterval"`
+	} `json:"jobs"`
+	Webhooks struct {
+		Timeout             time.Duration `json:"timeout"`
+		AllowPrivateTargets bool          `json:"allowPrivateTargets"`
+	} `json:"webhooks"`
+	Events struct {
+		Broker       string        `json:"broker"`
+		BacklogSize  int           `json:"backlogSize"`
+		ReplayWindow time.Duration `json:"replayWindow"`
+		PollInterval time.Duration `json:"pollInterval"`
+	} `json:"events"`
+	I18n struct {
+		DefaultLocale string `json:"defaultLocale"`
+	} `json:"i18n"`
+	Metrics struct {
+		Enabled bool   `json:"enabled"`
+		Address string `json:"address"`
+		Token   string `json:"token"`
+	} `json:"metrics"`
+	Tracing struct {
+		Exporter    string  `json:"exporter"`
+		Endpoint    string  `json:"endpoint"`
+		Insecure    bool    `json:"insecure"`
+		ServiceName string  `json:"serviceName"`
+		SampleRatio float64 `json:"sampleRatio"`
+	} `json:"tracing"`
+	RateLimit struct {
+		Store    string                     `json:"store"`
+		Policies map[string]RateLimitPolicy `json:"policies"`
+	} `json:"rateLimit"`
+	Logging struct {
+		Level     string        `json:"level"`
+		Format    string        `json:"format"`
+		SlowQuery time.Duration `json:"slowQuery"`
+		SQLParams bool          `json:"sqlParams"`
+	} `json:"logging"`
+}
+
+type QuotaPlan struct {
+	MaxStorageMB  int64 `json:"maxStorageMB"`
+	MaxPhotos     int64 `json:"maxPhotos"`
+	MaxFileSizeMB int64 `json:"maxFileSizeMB"`
+}
+
+type RateLimitPolicy struct {
+	Requests int           `json:"requests"`
+	Period   time.Duration `json:"period"`
+	Burst    int           `json:"burst"`
+	By       string        `json:"by"`
 }
 
-func GetConfig() Config {
-	var conf Config
+func (conf Config) IsProduction() bool {
+	return strings.EqualFold(conf.Env, "production")
+}
+
+func (conf Config) Validate() error {
+	var problems []string
+
+	if conf.JWT.Secret == "" {
+		problems = append(problems, "jwt.secret is required")
+	}
+	if conf.IsProduction() && conf.JWT.Secret == DefaultJWTSecret {
+		problems = append(problems, "jwt.secret must not be the default secret in production")
+	}
+	if conf.IsProduction() && conf.JWT.Secret != "" && len(conf.JWT.Secret) < minJWTSecretLen {
+		problems = append(problems, fmt.Sprintf("jwt.secret must be at least %d characters in production", minJWTSecretLen))
+	}
+	if conf.JWT.Expiry <= 0 {
+		problems = append(problems, "jwt.expiry must be positive")
+	}
+	if conf.Mysql.Host == "" {
+		problems = append(problems, "mysql.host is required")
+	}
+	if conf.Mysql.Name == "" {
+		problems = append(problems, "mysql.name is required")
+	}
+	if conf.IsProduction() && conf.Mysql.Pass == "" {
+		problems = append(problems, "mysql.pass is required in production")
+	}
+	if conf.Server.Address == "" {
+		problems = append(problems, "server.address is required")
+	}
+	if conf.Server.ShutdownTimeout <= 0 {
+		problems = append(problems, "server.shutdownTimeout must be positive")
+	}
+	if conf.Server.DrainDelay < 0 {
+		problems = append(problems, "server.drainDelay must not be negative")
+	}
+	if conf.Jobs.Concurrency <= 0 {
+		problems = append(problems, "jobs.concurrency must be positive")
+	}
+	if conf.Jobs.PollInterval <= 0 {
+		problems = append(problems, "jobs.pollInterval must be positive")
+	}
+	if conf.Events.Broker != "local" && conf.Events.Broker != "database" {
+		problems = append(problems, "events.broker must be local or database")
+	}
+	if conf.Events.ReplayWindow <= 0 {
+		problems = append(problems, "events.replayWindow must be positive")
+	}
+	if conf.Events.Broker == "database" && conf.Events.PollInterval <= 0 {
+		problems = append(problems, "events.pollInterval must be positive")
+	}
+	if _, err := logger.ParseLevel(conf.Logging.Level); err != nil {
+		problems = append(problems, fmt.Sprintf("logging.level %q is not valid", conf.Logging.Level))
+	}
+	if len(conf.Uploads.AllowedTypes) == 0 {
+		problems = append(problems, "uploads.allowedTypes must not be empty")
+	}
+	for _, filetype := range conf.Uploads.AllowedTypes {
+		if !IsSupportedImageType(filetype) {
+			problems = append(problems, fmt.Sprintf("uploads.allowedTypes %q is not supported", filetype))
+		}
+		if filetype == ImageTypeHEIC && conf.Images.HEICConverter == "" {
+			problems = append(problems, "images.heicConverter is required when image/heic uploads are allowed")
+		}
+	}
+	if conf.Images.WebPQuality < 1 || conf.Images.WebPQuality > 100 {
+		problems = append(problems, "images.webpQuality must be between 1 and 100")
+	}
+	if conf.Images.SigningKey == "" {
+		problems = append(problems, "images.signingKey is required")
+	}
+	if conf.Images.LinkTTL <= 0 {
+		problems = append(problems, "images.linkTTL must be positive")
+	}
+	if conf.IsProduction() && conf.Images.SigningKey != "" && len(conf.Images.SigningKey) < minJWTSecretLen {
+		problems = append(problems, fmt.Sprintf("images.signingKey must be at least %d characters in production", minJWTSecretLen))
+	}
+	if conf.Images.CacheDir == "" || conf.Images.CacheSizeMB <= 0 {
+		problems = append(problems, "images.cacheDir and a positive images.cacheSizeMB are required")
+	}
+	if conf.Images.MaxDimension <= 0 || conf.Images.TransformConcurrency <= 0 {
+		problems = append(problems, "images.maxDimension and images.transformConcurrency must be positive")
+	}
+	if conf.Uploads.MaxRequestSizeMB <= 0 {
+		problems = append(problems, "uploads.maxRequestSizeMB must be positive")
+	}
+	if conf.Uploads.MultipartMemoryMB <= 0 {
+		problems = append(problems, "uploads.multipartMemoryMB must be positive")
+	}
+	if conf.Uploads.MaxWidth < 0 || conf.Uploads.MaxHeight < 0 || conf.Uploads.MaxMegapixels < 0 {
+		problems = append(problems, "uploads image limits must not be negative, use 0 for unlimited")
+	}
+	if conf.Uploads.MaxConcurrent <= 0 {
+		problems = append(problems, "uploads.maxConcurrent must be positive")
+	}
+	if conf.Import.MaxArchiveSizeMB <= 0 {
+		problems = append(problems, "import.maxArchiveSizeMB must be positive")
+	}
+	for plan, quota := range conf.Quotas {
+		if quota.MaxStorageMB < 0 || quota.MaxPhotos < 0 || quota.MaxFileSizeMB < 0 {
+			problems = append(problems, fmt.Sprintf("quotas.%s limits must not be negative, use 0 for unlimited", plan))
+		}
+		if quota.MaxFileSizeMB > conf.Uploads.MaxRequestSizeMB {
+			problems = append(problems, fmt.Sprintf("quotas.%s.maxFileSizeMB is larger than uploads.maxRequestSizeMB", plan))
+		}
+	}
+	if conf.RateLimit.Store != "memory" && conf.RateLimit.Store != "database" {
+		problems = append(problems, "rateLimit.store must be memory or database")
+	}
+	for name, policy := range conf.RateLimit.Policies {
+		if policy.Requests < 0 || policy.Burst < 0 || (policy.Requests > 0 && policy.Period <= 0) {
+			problems = append(problems, fmt.Sprintf("rateLimit.policies.%s needs positive requests and period", name))
+		}
+		if policy.By != "user" && policy.By != "token" && policy.By != "ip" {
+			problems = append(problems, fmt.Sprintf("rateLimit.policies.%s.by must be user, token or ip", name))
+		}
+	}
+	if conf.I18n.DefaultLocale != "" && !i18n.IsSupported(conf.I18n.DefaultLocale) {
+		problems = append(problems, fmt.Sprintf("i18n.defaultLocale %q is not supported", conf.I18n.DefaultLocale))
+	}
+	if conf.IsProduction() && conf.Metrics.Enabled && conf.Metrics.Address == "" && conf.Metrics.Token == "" {
+		problems = append(problems, "metrics.token is required in production when metrics are served on the api address")
+	}
 
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath("./")
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config: %s", strings.Join(problems, "; "))
+	}
 
-	err := viper.ReadInConfig()
+	return nil
+}
+
+func newViper(path string) *viper.Viper {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if path != "" {
+		v.SetConfigFile(path)
+	} else {
+		v.SetConfigName("config")
+		v.AddConfigPath("./")
+	}
+
+	return v
+}
+
+func LoadConfig(path string) (conf Config, err error) {
+	v := newViper(path)
+
+	err = v.ReadInConfig()
+	if errors.As(err, &viper.ConfigFileNotFoundError{}) {
+		logger.Log.Warn().Err(err).Msg("reading config file, using environment only")
+		err = nil
+	}
+	if err != nil {
+		err = fmt.Errorf("reading config file: %w", err)
+
+		return
+	}
+
+	err = bindEnv(v)
 	if err != nil {
-		fmt.Println("error: ", err)
+		return
 	}
 
-	if err := viper.Unmarshal(&conf); err != nil {
-		panic(err)
+	err = v.Unmarshal(&conf)
+	if err != nil {
+		err = fmt.Errorf("decoding config: %w", err)
+
+		return
 	}
-	return conf
+
+	err = conf.Validate()
+
+	return
+}
+
+// bindEnv maps every config key to APP_<SECTION>_<KEY>, and to the contents
+// of the file named by APP_<SECTION>_<KEY>_FILE for docker secrets. Map
+// sections such as quotas take APP_QUOTAS_<NAME>_<KEY>, for names in the
+// config file or new ones. Any other APP_ variable is rejected, so a typo
+// doesn't silently leave the default in place.
+func bindEnv(v *viper.Viper) error {
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+
+	environ := map[string]struct{}{}
+	for _, variable := range os.Environ() {
+		name, _, _ := strings.Cut(variable, "=")
+		if strings.HasPrefix(name, EnvPrefix+"_") {
+			environ[name] = struct{}{}
+		}
+	}
+
+	for _, key := range expandKeys(v, configKeys(reflect.TypeOf(Config{}), ""), environ) {
+		err := v.BindEnv(key)
+		if err != nil {
+			return err
+		}
+
+		name := envName(key)
+		delete(environ, name)
+		file, ok := os.LookupEnv(name + "_FILE")
+		if !ok {
+			continue
+		}
+		delete(environ, name+"_FILE")
+		if _, ok := os.LookupEnv(name); ok {
+			return fmt.Errorf("both %s and %s_FILE are set", name, name)
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("reading %s_FILE: %w", name, err)
+		}
+		v.Set(key, strings.TrimSpace(string(content)))
+	}
+
+	if len(environ) > 0 {
+		names := make([]string, 0, len(environ))
+		for name := range environ {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return fmt.Errorf("unknown environment variables: %s", strings.Join(names, ", "))
+	}
+
+	return nil
+}
+
+func envName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// configKeys lists the keys of t. Fields of a map of structs come out with
+// a * in place of the map key, see expandKeys.
+func configKeys(t reflect.Type, prefix string) (keys []string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := prefix + strings.Split(field.Tag.Get("json"), ",")[0]
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			keys = append(keys, configKeys(field.Type, key+".")...)
+
+			continue
+		case field.Type.Kind() == reflect.Map && field.Type.Elem().Kind() == reflect.Struct:
+			keys = append(keys, configKeys(field.Type.Elem(), key+".*.")...)
+
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	return
+}
+
+// expandKeys replaces the * of map keys with the names set in the config
+// file, plus the names found in the environment so a whole entry can be
+// added through it. Names from the environment come out lowercase, the way
+// viper stores keys read from the file.
+func expandKeys(v *viper.Viper, keys []string, environ map[string]struct{}) (expanded []string) {
+	for _, key := range keys {
+		section, field, ok := strings.Cut(key, ".*.")
+		if !ok {
+			expanded = append(expanded, key)
+
+			continue
+		}
+
+		names := map[string]struct{}{}
+		for name := range v.GetStringMap(section) {
+			names[name] = struct{}{}
+		}
+		prefix := envName(section) + "_"
+		suffix := "_" + strings.ToUpper(field)
+		for variable := range environ {
+			variable = strings.TrimSuffix(variable, "_FILE")
+			if len(variable) > len(prefix)+len(suffix) && strings.HasPrefix(variable, prefix) && strings.HasSuffix(variable, suffix) {
+				names[strings.ToLower(variable[len(prefix):len(variable)-len(suffix)])] = struct{}{}
+			}
+		}
+
+		sorted := make([]string, 0, len(names))
+		for name := range names {
+			sorted = append(sorted, name)
+		}
+		sort.Strings(sorted)
+		for _, name := range sorted {
+			expanded = append(expanded, section+"."+name+"."+field)
+		}
+	}
+
+	return
 }
@@ -0,0 +1,161 @@
+package helpers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testConfig = `
+quotas:
+  user:
+    maxStorageMB: 1024
+    maxPhotos: 1000
+rateLimit:
+  policies:
+    api:
+      requests: 300
+      period: 1m
+      by: "user"
+`
+
+func TestBindEnv(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   map[string]string
+		check func(t *testing.T, conf Config)
+		err   string
+	}{
+		{
+			name: "plain key",
+			env:  map[string]string{"APP_IMAGES_LINKTTL": "2h"},
+			check: func(t *testing.T, conf Config) {
+				if conf.Images.LinkTTL != 2*time.Hour {
+					t.Errorf("images.linkTTL = %s", conf.Images.LinkTTL)
+				}
+			},
+		},
+		{
+			name: "existing map entry",
+			env:  map[string]string{"APP_QUOTAS_USER_MAXSTORAGEMB": "2048"},
+			check: func(t *testing.T, conf Config) {
+				if quota := conf.Quotas["user"]; quota.MaxStorageMB != 2048 || quota.MaxPhotos != 1000 {
+					t.Errorf("quotas.user = %+v", quota)
+				}
+			},
+		},
+		{
+			name: "new map entry",
+			env: map[string]string{
+				"APP_RATELIMIT_POLICIES_UPLOAD_REQUESTS": "5",
+				"APP_RATELIMIT_POLICIES_UPLOAD_BY":       "ip",
+			},
+			check: func(t *testing.T, conf Config) {
+				if policy := conf.RateLimit.Policies["upload"]; policy.Requests != 5 || policy.By != "ip" {
+					t.Errorf("rateLimit.policies.upload = %+v", policy)
+				}
+				if policy := conf.RateLimit.Policies["api"]; policy.Requests != 300 {
+					t.Errorf("rateLimit.policies.api = %+v", policy)
+				}
+			},
+		},
+		{
+			name: "map entry with underscore",
+			env:  map[string]string{"APP_QUOTAS_TEAM_PLUS_MAXPHOTOS": "5"},
+			check: func(t *testing.T, conf Config) {
+				if quota := conf.Quotas["team_plus"]; quota.MaxPhotos != 5 {
+					t.Errorf("quotas.team_plus = %+v", quota)
+				}
+			},
+		},
+		{
+			name: "unknown key",
+			env:  map[string]string{"APP_IMAGES_LINK_TTL": "2h"},
+			err:  "APP_IMAGES_LINK_TTL",
+		},
+		{
+			name: "unknown map field",
+			env:  map[string]string{"APP_QUOTAS_USER_MAXSTORAGE": "1"},
+			err:  "APP_QUOTAS_USER_MAXSTORAGE",
+		},
+		{
+			name: "file and value",
+			env:  map[string]string{"APP_METRICS_TOKEN": "a", "APP_METRICS_TOKEN_FILE": "token"},
+			err:  "both APP_METRICS_TOKEN and APP_METRICS_TOKEN_FILE",
+		},
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	err := os.WriteFile(path, []byte(testConfig), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			v := newViper(path)
+			err := v.ReadInConfig()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = bindEnv(v)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("err = %v, want %q", err, tt.err)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var conf Config
+			err = v.Unmarshal(&conf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, conf)
+		})
+	}
+}
+
+func TestBindEnvFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	secret := filepath.Join(dir, "token")
+	for name, content := range map[string]string{path: testConfig, secret: "s3cret\n"} {
+		err := os.WriteFile(name, []byte(content), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("APP_METRICS_TOKEN_FILE", secret)
+
+	v := newViper(path)
+	err := v.ReadInConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = bindEnv(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var conf Config
+	err = v.Unmarshal(&conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.Metrics.Token != "s3cret" {
+		t.Errorf("metrics.token = %q", conf.Metrics.Token)
+	}
+}
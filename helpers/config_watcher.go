@@ -0,0 +1,202 @@
+package helpers
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"rakamin/logger"
+	"reflect"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	ReloadApplied   = "applied"
+	ReloadUnchanged = "unchanged"
+	ReloadRejected  = "rejected"
+
+	reloadHistorySize = 20
+)
+
+type ConfigReload struct {
+	Version         int
+	Source          string
+	Status          string
+	Changed         []string
+	RestartRequired bool
+	Error           string
+	At              time.Time
+}
+
+type ConfigWatcher struct {
+	path      string
+	mu        sync.Mutex
+	current   Config
+	version   int
+	loadedAt  time.Time
+	history   []ConfigReload
+	listeners []func(Config)
+}
+
+func NewConfigWatcher(path string, conf Config) *ConfigWatcher {
+	return &ConfigWatcher{
+		path:     path,
+		current:  conf,
+		version:  1,
+		loadedAt: time.Now(),
+	}
+}
+
+// reloadable lists the settings that can change without a restart, keyed by
+// their config path.
+func reloadable(conf Config) map[string]interface{} {
+	return map[string]interface{}{
+		"logging.level":        conf.Logging.Level,
+		"uploads.allowedTypes": conf.Uploads.AllowedTypes,
+		"rateLimit.policies":   conf.RateLimit.Policies,
+	}
+}
+
+func applyReloadable(dst *Config, src Config) {
+	dst.Logging.Level = src.Logging.Level
+	dst.Uploads.AllowedTypes = src.Uploads.AllowedTypes
+	dst.RateLimit.Policies = src.RateLimit.Policies
+}
+
+func (w *ConfigWatcher) OnReload(listener func(Config)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.listeners = append(w.listeners, listener)
+}
+
+func (w *ConfigWatcher) Current() (conf Config, version int, loadedAt time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.current, w.version, w.loadedAt
+}
+
+func (w *ConfigWatcher) Reloadable() map[string]interface{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return reloadable(w.current)
+}
+
+func (w *ConfigWatcher) History() []ConfigReload {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]ConfigReload{}, w.history...)
+}
+
+func (w *ConfigWatcher) Start(ctx context.Context, wg *sync.WaitGroup) {
+	v := newViper(w.path)
+	err := v.ReadInConfig()
+	if err == nil {
+		v.OnConfigChange(func(event fsnotify.Event) {
+			w.Reload("file")
+		})
+		v.WatchConfig()
+	} else {
+		logger.Log.Warn().Err(err).Msg("config file not watched, reload with SIGHUP")
+	}
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer signal.Stop(hangup)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hangup:
+				w.Reload("signal")
+			}
+		}
+	}()
+}
+
+func (w *ConfigWatcher) Reload(source string) ConfigReload {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	reload := ConfigReload{
+		Version: w.version,
+		Source:  source,
+		At:      time.Now(),
+	}
+
+	next, err := LoadConfig(w.path)
+	if err != nil {
+		reload.Status = ReloadRejected
+		reload.Error = err.Error()
+		w.record(reload)
+		logger.Log.Warn().Err(err).Str("source", source).Msg("config reload rejected, keeping previous config")
+
+		return reload
+	}
+
+	before, after := reloadable(w.current), reloadable(next)
+	for key := range after {
+		if !reflect.DeepEqual(before[key], after[key]) {
+			reload.Changed = append(reload.Changed, key)
+		}
+	}
+	sort.Strings(reload.Changed)
+
+	applied := w.current
+	applyReloadable(&applied, next)
+	reload.RestartRequired = !reflect.DeepEqual(applied, next)
+
+	if len(reload.Changed) == 0 {
+		reload.Status = ReloadUnchanged
+		w.record(reload)
+		w.logReload(reload)
+
+		return reload
+	}
+
+	w.current = applied
+	w.version++
+	w.loadedAt = reload.At
+	reload.Version = w.version
+	reload.Status = ReloadApplied
+	for _, listener := range w.listeners {
+		listener(applied)
+	}
+	w.record(reload)
+	w.logReload(reload)
+
+	return reload
+}
+
+func (w *ConfigWatcher) record(reload ConfigReload) {
+	w.history = append(w.history, reload)
+	if len(w.history) > reloadHistorySize {
+		w.history = w.history[len(w.history)-reloadHistorySize:]
+	}
+}
+
+func (w *ConfigWatcher) logReload(reload ConfigReload) {
+	event := logger.Log.Info()
+	if reload.RestartRequired {
+		event = logger.Log.Warn()
+	}
+
+	event.Str("source", reload.Source).
+		Str("status", reload.Status).
+		Int("version", reload.Version).
+		Strs("changed", reload.Changed).
+		Bool("restartRequired", reload.RestartRequired).
+		Msg("config reloaded")
+}
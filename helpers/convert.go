@@ -0,0 +1,37 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ConvertImage runs an external converter from src to dst. {src}, {dst} and
+// {quality} in the command are filled in, and a command without {src} gets
+// both paths appended, which is how heif-convert takes them.
+func ConvertImage(ctx context.Context, command, src, dst string, quality int) error {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return fmt.Errorf("converting %s: no converter configured", src)
+	}
+
+	placeholders := strings.NewReplacer("{src}", src, "{dst}", dst, "{quality}", strconv.Itoa(quality))
+	if !strings.Contains(command, "{src}") {
+		args = append(args, src, dst)
+	}
+	for i := range args[1:] {
+		args[i+1] = placeholders.Replace(args[i+1])
+	}
+
+	output, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		os.Remove(dst)
+		return fmt.Errorf("converting %s: %w: %s", src, err, bytes.TrimSpace(output))
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package helpers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConvertImage(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		output  string
+		wantErr bool
+	}{
+		{"paths appended", "cp", "dst", false},
+		{"placeholders", "cp {src} {dst}", "dst", false},
+		{"quality placeholder", "cp {src} {dst}.q{quality}", "dst.q80", false},
+		{"failing command", "false", "", true},
+		{"empty command", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, "src")
+			dst := filepath.Join(dir, "dst")
+			err := os.WriteFile(src, []byte("source"), 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = ConvertImage(context.Background(), tt.command, src, dst, 80)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if _, statErr := os.Stat(dst); !os.IsNotExist(statErr) {
+					t.Errorf("failed conversion left %s behind", dst)
+				}
+				return
+			}
+
+			got, err := os.ReadFile(filepath.Join(dir, tt.output))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != "source" {
+				t.Errorf("%s = %q, want the source", tt.output, got)
+			}
+		})
+	}
+}
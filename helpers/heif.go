@@ -0,0 +1,284 @@
+package helpers
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+)
+
+// HEIF containers (HEIC and AVIF) carry HEVC and AV1 bitstreams that have no
+// Go decoder, so only their headers are parsed here. Pixel work on them goes
+// through the external converter.
+
+const maxHEIFMetaSize = 1 << 20
+
+var errHEIFInvalid = errors.New("heif: invalid container")
+
+type heifBox struct {
+	kind string
+	body []byte
+}
+
+// CanDecodeImage reports whether image.Decode can read pixels of the type.
+// HEIF isn't registered with the image package, since its ftyp signature
+// would also claim MP4, MOV and every other ISO-BMFF file.
+func CanDecodeImage(filetype string) bool {
+	return filetype != ImageTypeAVIF && filetype != ImageTypeHEIC
+}
+
+func sniffHEIF(header []byte) string {
+	if len(header) < 16 || string(header[4:8]) != "ftyp" {
+		return ""
+	}
+
+	size := int(binary.BigEndian.Uint32(header))
+	if size < 16 || size > len(header) {
+		size = len(header)
+	}
+
+	brands := append([]byte{}, header[8:12]...)
+	brands = append(brands, header[16:size]...)
+	for i := 0; i+4 <= len(brands); i += 4 {
+		switch string(brands[i : i+4]) {
+		case "avif", "avis":
+			return ImageTypeAVIF
+		case "heic", "heix", "heim", "heis", "hevc", "hevx":
+			return ImageTypeHEIC
+		}
+	}
+
+	return ""
+}
+
+// DecodeHEIFConfig reads the dimensions of the primary item, taking its
+// rotation into account.
+func DecodeHEIFConfig(r io.Reader) (config image.Config, err error) {
+	meta, err := readHEIFMeta(r)
+	if err != nil {
+		return
+	}
+	if len(meta) < 4 {
+		err = errHEIFInvalid
+		return
+	}
+
+	boxes, err := heifBoxes(meta[4:])
+	if err != nil {
+		return
+	}
+
+	var (
+		primary    uint32
+		hasPrimary bool
+		properties []heifBox
+		ipma       []byte
+	)
+	for _, box := range boxes {
+		switch box.kind {
+		case "pitm":
+			primary, hasPrimary = heifPrimaryItem(box.body)
+		case "iprp":
+			children, childErr := heifBoxes(box.body)
+			if childErr != nil {
+				err = childErr
+				return
+			}
+			for _, child := range children {
+				switch child.kind {
+				case "ipco":
+					properties, err = heifBoxes(child.body)
+					if err != nil {
+						return
+					}
+				case "ipma":
+					ipma = child.body
+				}
+			}
+		}
+	}
+
+	associated := properties
+	if hasPrimary && ipma != nil {
+		associated = nil
+		for _, index := range heifAssociations(ipma, primary) {
+			if index > 0 && index <= len(properties) {
+				associated = append(associated, properties[index-1])
+			}
+		}
+	}
+
+	var found, rotated bool
+	for _, property := range associated {
+		switch property.kind {
+		case "ispe":
+			if found || len(property.body) < 12 {
+				continue
+			}
+			config.Width = int(binary.BigEndian.Uint32(property.body[4:]))
+			config.Height = int(binary.BigEndian.Uint32(property.body[8:]))
+			found = true
+		case "irot":
+			if len(property.body) > 0 {
+				angle := property.body[0] & 0x3
+				rotated = angle == 1 || angle == 3
+			}
+		}
+	}
+	if !found || config.Width <= 0 || config.Height <= 0 {
+		err = errHEIFInvalid
+		return
+	}
+	if rotated {
+		config.Width, config.Height = config.Height, config.Width
+	}
+	config.ColorModel = color.YCbCrModel
+
+	return
+}
+
+func readHEIFMeta(r io.Reader) (meta []byte, err error) {
+	header := make([]byte, 16)
+	for {
+		_, err = io.ReadFull(r, header[:8])
+		if err != nil {
+			err = errHEIFInvalid
+			return
+		}
+
+		size := uint64(binary.BigEndian.Uint32(header))
+		headerSize := uint64(8)
+		if size == 1 {
+			_, err = io.ReadFull(r, header[8:16])
+			if err != nil {
+				err = errHEIFInvalid
+				return
+			}
+			size = binary.BigEndian.Uint64(header[8:])
+			headerSize = 16
+		}
+		if size < headerSize {
+			err = errHEIFInvalid
+			return
+		}
+
+		if string(header[4:8]) == "meta" {
+			if size-headerSize > maxHEIFMetaSize {
+				err = errHEIFInvalid
+				return
+			}
+			meta = make([]byte, size-headerSize)
+			_, err = io.ReadFull(r, meta)
+			if err != nil {
+				err = errHEIFInvalid
+			}
+
+			return
+		}
+
+		_, err = io.CopyN(io.Discard, r, int64(size-headerSize))
+		if err != nil {
+			err = errHEIFInvalid
+			return
+		}
+	}
+}
+
+func heifBoxes(data []byte) (boxes []heifBox, err error) {
+	for len(data) > 0 {
+		if len(data) < 8 {
+			err = errHEIFInvalid
+			return
+		}
+
+		size := uint64(binary.BigEndian.Uint32(data))
+		headerSize := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				err = errHEIFInvalid
+				return
+			}
+			size = binary.BigEndian.Uint64(data[8:])
+			headerSize = 16
+		}
+		if size < headerSize || size > uint64(len(data)) {
+			err = errHEIFInvalid
+			return
+		}
+
+		boxes = append(boxes, heifBox{kind: string(data[4:8]), body: data[headerSize:size]})
+		data = data[size:]
+	}
+
+	return
+}
+
+func heifPrimaryItem(body []byte) (id uint32, ok bool) {
+	if len(body) < 6 {
+		return
+	}
+	if body[0] == 0 {
+		return uint32(binary.BigEndian.Uint16(body[4:])), true
+	}
+	if len(body) < 8 {
+		return
+	}
+
+	return binary.BigEndian.Uint32(body[4:]), true
+}
+
+// heifAssociations returns the 1-based ipco indices associated with item.
+func heifAssociations(body []byte, item uint32) (indices []int) {
+	if len(body) < 8 {
+		return
+	}
+	version := body[0]
+	largeIndex := body[3]&1 == 1
+	count := binary.BigEndian.Uint32(body[4:])
+	data := body[8:]
+
+	for i := uint32(0); i < count; i++ {
+		var id uint32
+		if version < 1 {
+			if len(data) < 3 {
+				return nil
+			}
+			id = uint32(binary.BigEndian.Uint16(data))
+			data = data[2:]
+		} else {
+			if len(data) < 5 {
+				return nil
+			}
+			id = binary.BigEndian.Uint32(data)
+			data = data[4:]
+		}
+
+		associations := int(data[0])
+		data = data[1:]
+		for j := 0; j < associations; j++ {
+			var index int
+			if largeIndex {
+				if len(data) < 2 {
+					return nil
+				}
+				index = int(binary.BigEndian.Uint16(data) & 0x7fff)
+				data = data[2:]
+			} else {
+				if len(data) < 1 {
+					return nil
+				}
+				index = int(data[0] & 0x7f)
+				data = data[1:]
+			}
+			if id == item {
+				indices = append(indices, index)
+			}
+		}
+	}
+
+	return
+}
@@ -0,0 +1,137 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func heifTestBox(kind string, body ...[]byte) []byte {
+	content := bytes.Join(body, nil)
+	box := make([]byte, 8, 8+len(content))
+	binary.BigEndian.PutUint32(box, uint32(8+len(content)))
+	copy(box[4:], kind)
+
+	return append(box, content...)
+}
+
+func heifTestUint16(v uint16) []byte {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, v)
+
+	return b
+}
+
+func heifTestUint32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+
+	return b
+}
+
+var heifTestFullBox = []byte{0, 0, 0, 0}
+
+func heifTestFtyp(major string, compatible ...string) []byte {
+	body := [][]byte{[]byte(major), heifTestUint32(0)}
+	for _, brand := range compatible {
+		body = append(body, []byte(brand))
+	}
+
+	return heifTestBox("ftyp", body...)
+}
+
+func heifTestISPE(width, height uint32) []byte {
+	return heifTestBox("ispe", heifTestFullBox, heifTestUint32(width), heifTestUint32(height))
+}
+
+// heifTestFile builds an ftyp and meta box whose primary item 1 is
+// associated with the given 1-based ipco indices.
+func heifTestFile(properties [][]byte, associations ...byte) []byte {
+	ipco := heifTestBox("ipco", properties...)
+	ipma := heifTestBox("ipma", heifTestFullBox, heifTestUint32(2),
+		heifTestUint16(2), []byte{1, 1},
+		heifTestUint16(1), []byte{byte(len(associations))}, associations)
+	meta := heifTestBox("meta", heifTestFullBox,
+		heifTestBox("pitm", heifTestFullBox, heifTestUint16(1)),
+		heifTestBox("iprp", ipco, ipma))
+
+	return append(heifTestFtyp("heic", "mif1", "heic"), meta...)
+}
+
+func TestSniffHEIF(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []byte
+		want   string
+	}{
+		{"heic major brand", heifTestFtyp("heic", "mif1"), ImageTypeHEIC},
+		{"heic compatible brand", heifTestFtyp("mif1", "heic"), ImageTypeHEIC},
+		{"avif", heifTestFtyp("avif", "mif1", "miaf"), ImageTypeAVIF},
+		{"avif sequence", heifTestFtyp("msf1", "avis"), ImageTypeAVIF},
+		{"mp4", heifTestFtyp("isom", "iso2", "mp41"), ""},
+		{"quicktime", heifTestFtyp("qt  "), ""},
+		{"too short", []byte("\x00\x00\x00\x0cftyp"), ""},
+		{"jpeg", []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00\x01\x01\x00\x00\x01"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sniffHEIF(tt.header); got != tt.want {
+				t.Errorf("sniffHEIF = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeHEIFConfig(t *testing.T) {
+	rotate90 := heifTestBox("irot", []byte{1})
+	rotate180 := heifTestBox("irot", []byte{2})
+
+	largeBox := make([]byte, 16)
+	binary.BigEndian.PutUint32(largeBox, 1)
+	copy(largeBox[4:], "free")
+	binary.BigEndian.PutUint64(largeBox[8:], 16)
+
+	tests := []struct {
+		name    string
+		file    []byte
+		width   int
+		height  int
+		wantErr bool
+	}{
+		{"single item", heifTestFile([][]byte{heifTestISPE(640, 480)}, 1), 640, 480, false},
+		{"rotated 90", heifTestFile([][]byte{heifTestISPE(640, 480), rotate90}, 1, 2), 480, 640, false},
+		{"rotated 180", heifTestFile([][]byte{heifTestISPE(640, 480), rotate180}, 1, 2), 640, 480, false},
+		{"primary after thumbnail", heifTestFile([][]byte{heifTestISPE(160, 120), heifTestISPE(4032, 3024)}, 2), 4032, 3024, false},
+		{"rotation of another item", heifTestFile([][]byte{heifTestISPE(640, 480), rotate90}, 1), 640, 480, false},
+		{"64-bit box before meta", append(largeBox, heifTestFile([][]byte{heifTestISPE(32, 16)}, 1)...), 32, 16, false},
+		{"no ispe", heifTestFile([][]byte{rotate90}, 1), 0, 0, true},
+		{"zero size", heifTestFile([][]byte{heifTestISPE(0, 480)}, 1), 0, 0, true},
+		{"no meta", heifTestFtyp("heic", "mif1"), 0, 0, true},
+		{"truncated", heifTestFile([][]byte{heifTestISPE(640, 480)}, 1)[:40], 0, 0, true},
+		{"box larger than parent", append(heifTestFtyp("heic"), heifTestBox("meta", heifTestFullBox, []byte("\x00\x00\x10\x00ipco"))...), 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := DecodeHEIFConfig(bytes.NewReader(tt.file))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (config.Width != tt.width || config.Height != tt.height) {
+				t.Errorf("size %dx%d, want %dx%d", config.Width, config.Height, tt.width, tt.height)
+			}
+		})
+	}
+}
+
+func TestDecodeHEIFConfigRejectsOversizedMeta(t *testing.T) {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, maxHEIFMetaSize+9)
+	copy(header[4:], "meta")
+
+	_, err := DecodeHEIFConfig(bytes.NewReader(append(heifTestFtyp("heic"), header...)))
+	if err == nil {
+		t.Fatal("expected an error for a meta box over the size limit")
+	}
+}
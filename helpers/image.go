@@ -0,0 +1,147 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"rakamin/apperror"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	ImageDir   = "public/images/"
+	VariantDir = ImageDir + "variants/"
+
+	ImageTypeJPEG = "image/jpeg"
+	ImageTypeGIF  = "image/gif"
+	ImageTypePNG  = "image/png"
+	ImageTypeWebP = "image/webp"
+	ImageTypeAVIF = "image/avif"
+	ImageTypeHEIC = "image/heic"
+)
+
+var (
+	ErrInvalidFileType  = apperror.ErrInvalidFileType
+	SupportedImageTypes = []string{ImageTypeJPEG, ImageTypeGIF, ImageTypePNG, ImageTypeWebP, ImageTypeAVIF, ImageTypeHEIC}
+	allowedImageTypes   atomic.Value
+
+	// Variants get fixed extensions, the system mime table may prefer odd
+	// ones like .pjpeg that converters don't recognise.
+	variantExtensions = map[string]string{
+		ImageTypeJPEG: ".jpg",
+		ImageTypePNG:  ".png",
+		ImageTypeWebP: ".webp",
+	}
+)
+
+func init() {
+	// The system mime table doesn't always know the newer formats, and
+	// NewImagePath picks the extension from it.
+	mime.AddExtensionType(".webp", ImageTypeWebP)
+	mime.AddExtensionType(".avif", ImageTypeAVIF)
+	mime.AddExtensionType(".heic", ImageTypeHEIC)
+
+	SetAllowedImageTypes(SupportedImageTypes)
+}
+
+func IsSupportedImageType(filetype string) bool {
+	for _, supported := range SupportedImageTypes {
+		if filetype == supported {
+			return true
+		}
+	}
+
+	return false
+}
+
+func SetAllowedImageTypes(filetypes []string) {
+	allowedImageTypes.Store(append([]string{}, filetypes...))
+}
+
+func IsAllowedImageType(filetype string) bool {
+	for _, allowed := range allowedImageTypes.Load().([]string) {
+		if filetype == allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+func SniffImageType(header []byte) string {
+	if filetype := sniffHEIF(header); filetype != "" {
+		return filetype
+	}
+
+	return http.DetectContentType(header)
+}
+
+func DetectImageType(header []byte) (filetype string, err error) {
+	filetype = SniffImageType(header)
+	if !IsAllowedImageType(filetype) {
+		err = ErrInvalidFileType
+	}
+
+	return
+}
+
+func NewImagePath(filetype string) (path string, err error) {
+	fileFormat, err := mime.ExtensionsByType(filetype)
+	if err != nil || len(fileFormat) == 0 {
+		err = apperror.ErrInvalidFileType
+		return
+	}
+
+	path = fmt.Sprintf("%s%s%s", ImageDir, GetUUID(), fileFormat[len(fileFormat)-1])
+
+	return
+}
+
+// IsWebDisplayable reports whether browsers can render the type directly.
+// Other types get a converted display variant.
+func IsWebDisplayable(filetype string) bool {
+	return filetype != ImageTypeHEIC
+}
+
+// VariantPath names a derived file of the image at path. Variants share the
+// base name of their source, so they can be found without a lookup.
+func VariantPath(path, filetype string) (variant string, err error) {
+	extension, ok := variantExtensions[filetype]
+	if !ok {
+		err = apperror.ErrInvalidFileType
+		return
+	}
+
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	variant = fmt.Sprintf("%s%s%s", VariantDir, base, extension)
+
+	return
+}
+
+// RemoveImageFiles removes the given images along with all their variants.
+func RemoveImageFiles(paths ...string) error {
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+
+		base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		variants, err := filepath.Glob(VariantDir + base + ".*")
+		if err != nil {
+			return err
+		}
+
+		for _, file := range append(variants, path) {
+			err = os.Remove(file)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"rakamin/apperror"
+
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	sniffLen = 512
+
+	// UploadTempPrefix starts the name of files still being written. An
+	// interrupted upload can leave one behind.
+	UploadTempPrefix = ".upload-"
+)
+
+// ImageLimits of zero mean unlimited. MaxPixels guards against decompression
+// bombs whose header claims huge dimensions in a tiny file.
+type ImageLimits struct {
+	MaxWidth  int
+	MaxHeight int
+	MaxPixels int64
+}
+
+type StoredImage struct {
+	Path     string
+	Filetype string
+	Hash     string
+	Size     int64
+	Width    int
+	Height   int
+}
+
+type ImageStore struct {
+	Limits ImageLimits
+	slots  chan struct{}
+}
+
+func NewImageStore(limits ImageLimits, concurrency int) *ImageStore {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	return &ImageStore{
+		Limits: limits,
+		slots:  make(chan struct{}, concurrency),
+	}
+}
+
+// Save streams src into the image directory, hashing it on the way and
+// sniffing only the leading bytes, then verifies the file decodes as an image
+// within the configured limits. Nothing is left on disk when it fails.
+func (s *ImageStore) Save(ctx context.Context, src io.Reader, maxSize int64) (stored StoredImage, err error) {
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	case <-ctx.Done():
+		err = ctx.Err()
+		return
+	}
+
+	header := make([]byte, sniffLen)
+	n, err := io.ReadFull(src, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		err = apperror.ErrInvalidFile.Wrap(err)
+		return
+	}
+	header = header[:n]
+
+	stored.Filetype, err = DetectImageType(header)
+	if err != nil {
+		return
+	}
+
+	err = os.MkdirAll(ImageDir, 0750)
+	if err != nil {
+		err = apperror.ErrFileNotSaved.Wrap(err)
+		return
+	}
+
+	tmp, err := os.CreateTemp(ImageDir, UploadTempPrefix+"*")
+	if err != nil {
+		err = apperror.ErrFileNotSaved.Wrap(err)
+		return
+	}
+	defer func() {
+		tmp.Close()
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	body := io.MultiReader(bytes.NewReader(header), src)
+	if maxSize > 0 {
+		body = io.LimitReader(body, maxSize+1)
+	}
+
+	hash := sha256.New()
+	stored.Size, err = io.Copy(io.MultiWriter(tmp, hash), body)
+	if err != nil {
+		err = apperror.ErrFileNotSaved.Wrap(err)
+		return
+	}
+	if maxSize > 0 && stored.Size > maxSize {
+		err = apperror.ErrFileTooLarge
+		return
+	}
+	stored.Hash = hex.EncodeToString(hash.Sum(nil))
+
+	stored.Width, stored.Height, err = s.verify(tmp, stored.Filetype)
+	if err != nil {
+		return
+	}
+
+	stored.Path, err = NewImagePath(stored.Filetype)
+	if err != nil {
+		return
+	}
+
+	err = os.Rename(tmp.Name(), stored.Path)
+	if err != nil {
+		err = apperror.ErrFileNotSaved.Wrap(err)
+	}
+
+	return
+}
+
+func (s *ImageStore) verify(file *os.File, filetype string) (width, height int, err error) {
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		return
+	}
+
+	var config image.Config
+	if CanDecodeImage(filetype) {
+		config, _, err = image.DecodeConfig(file)
+	} else {
+		config, err = DecodeHEIFConfig(file)
+	}
+	if err != nil {
+		err = apperror.ErrInvalidFile.Wrap(err)
+		return
+	}
+	if (s.Limits.MaxWidth > 0 && config.Width > s.Limits.MaxWidth) ||
+		(s.Limits.MaxHeight > 0 && config.Height > s.Limits.MaxHeight) ||
+		(s.Limits.MaxPixels > 0 && int64(config.Width)*int64(config.Height) > s.Limits.MaxPixels) {
+		err = apperror.ErrImageTooLarge
+		return
+	}
+
+	if !CanDecodeImage(filetype) {
+		return config.Width, config.Height, nil
+	}
+
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		return
+	}
+
+	_, _, err = image.Decode(file)
+	if err != nil {
+		err = apperror.ErrInvalidFile.Wrap(err)
+		return
+	}
+
+	return config.Width, config.Height, nil
+}
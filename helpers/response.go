@@ -1,9 +1,24 @@
 package helpers
 
+import (
+	"errors"
+	"net/http"
+	"rakamin/apperror"
+	"rakamin/i18n"
+	"rakamin/logger"
+	"rakamin/tracing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
 type BaseResponse struct {
 	Meta struct {
-		Message string   `json:"message"`
-		Errors  []string `json:"error,omitempty"`
+		Message string                `json:"message"`
+		Code    string                `json:"code,omitempty"`
+		TraceID string                `json:"traceId,omitempty"`
+		Errors  []string              `json:"error,omitempty"`
+		Fields  []apperror.FieldError `json:"fields,omitempty"`
 	} `json:"meta"`
 	Data interface{} `json:"data"`
 }
@@ -25,9 +40,35 @@ func NewSuccessInsertResponse(param interface{}) BaseResponse {
 }
 
 func NewErrorResponse(err error) BaseResponse {
+	return NewLocalizedErrorResponse(i18n.DefaultLocale(), err)
+}
+
+func NewLocalizedErrorResponse(locale string, err error) BaseResponse {
+	appErr := apperror.From(err)
+	message := i18n.Error(locale, appErr)
+
 	response := BaseResponse{}
-	response.Meta.Message = "Something wrong"
-	response.Meta.Errors = []string{err.Error()}
+	response.Meta.Message = message
+	response.Meta.Code = appErr.Code
+	response.Meta.Errors = []string{message}
+	response.Meta.Fields = appErr.Fields
+
+	var validationErr validator.ValidationErrors
+	if len(appErr.Fields) == 0 && errors.As(appErr, &validationErr) {
+		response.Meta.Fields = i18n.FieldErrors(locale, validationErr)
+	}
 
 	return response
 }
+
+func AbortWithError(g *gin.Context, err error) {
+	appErr := apperror.From(err)
+	traceId := tracing.TraceID(g.Request.Context())
+	if appErr.Status >= http.StatusInternalServerError {
+		logger.FromContext(g.Request.Context()).Error().Err(err).Str("code", appErr.Code).Msg("request failed")
+	}
+
+	response := NewLocalizedErrorResponse(i18n.FromContext(g), appErr)
+	response.Meta.TraceID = traceId
+	g.AbortWithStatusJSON(appErr.Status, response)
+}
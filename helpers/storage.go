@@ -0,0 +1,25 @@
+package helpers
+
+import "os"
+
+func CheckWritable(dirs ...string) error {
+	for _, dir := range dirs {
+		err := os.MkdirAll(dir, 0755)
+		if err != nil {
+			return err
+		}
+
+		file, err := os.CreateTemp(dir, ".readyz-*")
+		if err != nil {
+			return err
+		}
+		file.Close()
+
+		err = os.Remove(file.Name())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
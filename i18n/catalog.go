@@ -0,0 +1,134 @@
+package i18n
+
+var catalogs = map[string]map[string]string{
+	English: {
+		"error.INTERNAL_ERROR":        "something went wrong",
+		"error.VALIDATION_FAILED":     "request validation failed",
+		"error.MALFORMED_REQUEST":     "request body is malformed",
+		"error.NOT_FOUND":             "resource not found",
+		"error.CONFLICT":              "resource already exists",
+		"error.NOT_READY":             "service is not ready",
+		"error.RATE_LIMITED":          "too many requests, try again later",
+		"error.TOKEN_MISSING":         "token not found",
+		"error.TOKEN_INVALID":         "invalid token",
+		"error.SESSION_REVOKED":       "invalid session",
+		"error.INVALID_CREDENTIALS":   "wrong email or password",
+		"error.FORBIDDEN":             "access denied",
+		"error.ACCOUNT_DISABLED":      "account is disabled",
+		"error.USER_NOT_FOUND":        "user not found",
+		"error.EMAIL_TAKEN":           "email is already registered",
+		"error.SESSION_NOT_FOUND":     "session not found",
+		"error.PHOTO_NOT_FOUND":       "photo not found",
+		"error.PHOTO_NOT_IN_TRASH":    "photo not found in trash",
+		"error.INVALID_PHOTO_ID":      "invalid photo id",
+		"error.INVALID_FILE":          "invalid file",
+		"error.INVALID_FILE_TYPE":     "invalid file type",
+		"error.FILE_UNREADABLE":       "can't open file",
+		"error.FILE_NOT_SAVED":        "can't save file",
+		"error.FILE_TOO_LARGE":        "file exceeds the maximum allowed size",
+		"error.IMAGE_TOO_LARGE":       "image dimensions exceed the allowed limit",
+		"error.REQUEST_TOO_LARGE":     "request body is too large",
+		"error.INVALID_TRANSFORM":     "invalid transform parameters",
+		"error.INVALID_SIGNATURE":     "invalid or missing signature",
+		"error.SIGNATURE_EXPIRED":     "signed link has expired",
+		"error.CANNOT_TRANSFORM":      "photo format can't be transformed",
+		"error.QUOTA_EXCEEDED":        "storage quota exceeded",
+		"error.PHOTO_LIMIT_REACHED":   "photo limit reached",
+		"error.INVALID_ARCHIVE":       "invalid zip archive",
+		"error.IMPORT_NOT_FOUND":      "import not found",
+		"error.IMPORT_INTERRUPTED":    "import was interrupted",
+		"error.INVALID_SIDECAR":       "metadata file is invalid",
+		"error.INVALID_METADATA":      "photo metadata is invalid",
+		"error.TOO_MANY_FILES":        "archive contains too many files",
+		"error.SYSTEM_FILE":           "system file",
+		"error.EXPORT_NOT_FOUND":      "export not found",
+		"error.EXPORT_LINK_EXPIRED":   "download link is invalid or expired",
+		"error.JOB_NOT_FOUND":         "job not found",
+		"error.JOB_NOT_RETRYABLE":     "job not found or not retryable",
+		"error.WEBHOOK_NOT_FOUND":     "webhook not found",
+		"error.WEBHOOK_INVALID_URL":   "webhook url must use http or https",
+		"error.WEBHOOK_INVALID_EVENT": "unknown event type",
+		"error.DELIVERY_NOT_FOUND":    "delivery not found",
+
+		"validation.username":     "{0} must be 3-30 characters of letters, digits, '.', '_' or '-'",
+		"validation.unique_email": "{0} is already registered",
+		"validation.title":        "{0} must be a single line of at most {1} characters",
+		"validation.locale":       "{0} must be one of: en, id",
+
+		"notification.account_restored.title":   "Account deletion cancelled",
+		"notification.account_restored.message": "Your account was scheduled for deletion and has been restored because you logged in again",
+		"notification.new_login.title":          "New login",
+		"notification.new_login.message":        "Your account was accessed from a new device (%s) at %s",
+		"notification.export_ready.title":       "Your data export is ready",
+		"notification.export_ready.message":     "Get the download link from /api/v1/users/export/%s before %s",
+		"notification.import_finished.title":    "Your photo import has finished",
+		"notification.import_finished.message":  "%d imported, %d skipped, %d rejected",
+		"notification.import_failed.title":      "Your photo import failed",
+		"notification.import_failed.message":    "%s, %d photos were imported before it stopped",
+	},
+	Indonesian: {
+		"error.INTERNAL_ERROR":        "terjadi kesalahan",
+		"error.VALIDATION_FAILED":     "validasi permintaan gagal",
+		"error.MALFORMED_REQUEST":     "format permintaan tidak valid",
+		"error.NOT_FOUND":             "data tidak ditemukan",
+		"error.CONFLICT":              "data sudah ada",
+		"error.NOT_READY":             "layanan belum siap",
+		"error.RATE_LIMITED":          "terlalu banyak permintaan, coba lagi nanti",
+		"error.TOKEN_MISSING":         "token tidak ditemukan",
+		"error.TOKEN_INVALID":         "token tidak valid",
+		"error.SESSION_REVOKED":       "sesi tidak valid",
+		"error.INVALID_CREDENTIALS":   "email atau kata sandi salah",
+		"error.FORBIDDEN":             "akses ditolak",
+		"error.ACCOUNT_DISABLED":      "akun dinonaktifkan",
+		"error.USER_NOT_FOUND":        "pengguna tidak ditemukan",
+		"error.EMAIL_TAKEN":           "email sudah terdaftar",
+		"error.SESSION_NOT_FOUND":     "sesi tidak ditemukan",
+		"error.PHOTO_NOT_FOUND":       "foto tidak ditemukan",
+		"error.PHOTO_NOT_IN_TRASH":    "foto tidak ditemukan di tempat sampah",
+		"error.INVALID_PHOTO_ID":      "id foto tidak valid",
+		"error.INVALID_FILE":          "berkas tidak valid",
+		"error.INVALID_FILE_TYPE":     "tipe berkas tidak valid",
+		"error.FILE_UNREADABLE":       "berkas tidak dapat dibuka",
+		"error.FILE_NOT_SAVED":        "berkas tidak dapat disimpan",
+		"error.FILE_TOO_LARGE":        "ukuran berkas melebihi batas",
+		"error.IMAGE_TOO_LARGE":       "dimensi gambar melebihi batas",
+		"error.REQUEST_TOO_LARGE":     "ukuran permintaan terlalu besar",
+		"error.INVALID_TRANSFORM":     "parameter transformasi tidak valid",
+		"error.INVALID_SIGNATURE":     "tanda tangan tidak valid atau tidak ada",
+		"error.SIGNATURE_EXPIRED":     "tautan bertanda tangan sudah kedaluwarsa",
+		"error.CANNOT_TRANSFORM":      "format foto tidak dapat ditransformasi",
+		"error.QUOTA_EXCEEDED":        "kuota penyimpanan terlampaui",
+		"error.PHOTO_LIMIT_REACHED":   "batas jumlah foto tercapai",
+		"error.INVALID_ARCHIVE":       "arsip zip tidak valid",
+		"error.IMPORT_NOT_FOUND":      "impor tidak ditemukan",
+		"error.IMPORT_INTERRUPTED":    "impor terhenti",
+		"error.INVALID_SIDECAR":       "berkas metadata tidak valid",
+		"error.INVALID_METADATA":      "metadata foto tidak valid",
+		"error.TOO_MANY_FILES":        "arsip berisi terlalu banyak berkas",
+		"error.SYSTEM_FILE":           "berkas sistem",
+		"error.EXPORT_NOT_FOUND":      "ekspor tidak ditemukan",
+		"error.EXPORT_LINK_EXPIRED":   "tautan unduhan tidak valid atau sudah kedaluwarsa",
+		"error.JOB_NOT_FOUND":         "pekerjaan tidak ditemukan",
+		"error.JOB_NOT_RETRYABLE":     "pekerjaan tidak ditemukan atau tidak dapat diulang",
+		"error.WEBHOOK_NOT_FOUND":     "webhook tidak ditemukan",
+		"error.WEBHOOK_INVALID_URL":   "url webhook harus menggunakan http atau https",
+		"error.WEBHOOK_INVALID_EVENT": "tipe event tidak dikenal",
+		"error.DELIVERY_NOT_FOUND":    "pengiriman tidak ditemukan",
+
+		"validation.username":     "{0} harus terdiri dari 3-30 karakter huruf, angka, '.', '_' atau '-'",
+		"validation.unique_email": "{0} sudah terdaftar",
+		"validation.title":        "{0} harus berupa satu baris dengan maksimal {1} karakter",
+		"validation.locale":       "{0} harus salah satu dari: en, id",
+
+		"notification.account_restored.title":   "Penghapusan akun dibatalkan",
+		"notification.account_restored.message": "Akun Anda dijadwalkan untuk dihapus dan telah dipulihkan karena Anda masuk kembali",
+		"notification.new_login.title":          "Login baru",
+		"notification.new_login.message":        "Akun Anda diakses dari perangkat baru (%s) di %s",
+		"notification.export_ready.title":       "Ekspor data Anda sudah siap",
+		"notification.export_ready.message":     "Ambil tautan unduhan melalui /api/v1/users/export/%s sebelum %s",
+		"notification.import_finished.title":    "Impor foto Anda telah selesai",
+		"notification.import_finished.message":  "%d diimpor, %d dilewati, %d ditolak",
+		"notification.import_failed.title":      "Impor foto Anda gagal",
+		"notification.import_failed.message":    "%s, %d foto sudah diimpor sebelum berhenti",
+	},
+}
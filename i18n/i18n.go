@@ -0,0 +1,90 @@
+package i18n
+
+import (
+	"fmt"
+	"rakamin/apperror"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+const (
+	English    = "en"
+	Indonesian = "id"
+
+	ContextKey = "locale"
+)
+
+var (
+	defaultLocale = English
+	locales       = []string{English, Indonesian}
+	matcher       = language.NewMatcher([]language.Tag{language.English, language.Indonesian})
+)
+
+func SetDefaultLocale(locale string) {
+	if IsSupported(locale) {
+		defaultLocale = locale
+	}
+}
+
+func DefaultLocale() string {
+	return defaultLocale
+}
+
+func IsSupported(locale string) bool {
+	_, ok := catalogs[locale]
+
+	return ok
+}
+
+func Preferred(locale string) string {
+	if IsSupported(locale) {
+		return locale
+	}
+
+	return defaultLocale
+}
+
+func Negotiate(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return defaultLocale
+	}
+
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return defaultLocale
+	}
+
+	return locales[index]
+}
+
+func FromContext(g *gin.Context) string {
+	return Preferred(g.GetString(ContextKey))
+}
+
+func T(locale, key string, args ...interface{}) string {
+	message, ok := catalogs[Preferred(locale)][key]
+	if !ok {
+		message, ok = catalogs[defaultLocale][key]
+	}
+	if !ok {
+		message = key
+	}
+
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+
+	return message
+}
+
+func Error(locale string, err *apperror.Error) string {
+	key := "error." + err.Code
+	message := T(locale, key)
+	if message == key {
+		return err.Message
+	}
+
+	return message
+}
@@ -0,0 +1,91 @@
+package i18n
+
+import (
+	"rakamin/apperror"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	id_translations "github.com/go-playground/validator/v10/translations/id"
+)
+
+const validationPrefix = "validation."
+
+var translators = map[string]ut.Translator{}
+
+func RegisterValidator(validate *validator.Validate) (err error) {
+	universal := ut.New(en.New(), en.New(), id.New())
+
+	english, _ := universal.GetTranslator(English)
+	err = en_translations.RegisterDefaultTranslations(validate, english)
+	if err != nil {
+		return
+	}
+
+	indonesian, _ := universal.GetTranslator(Indonesian)
+	err = id_translations.RegisterDefaultTranslations(validate, indonesian)
+	if err != nil {
+		return
+	}
+
+	translators[English] = english
+	translators[Indonesian] = indonesian
+
+	for locale, translator := range translators {
+		err = registerCustomTranslations(validate, locale, translator)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func registerCustomTranslations(validate *validator.Validate, locale string, translator ut.Translator) (err error) {
+	for key, text := range catalogs[locale] {
+		if !strings.HasPrefix(key, validationPrefix) {
+			continue
+		}
+
+		tag, text := strings.TrimPrefix(key, validationPrefix), text
+		err = validate.RegisterTranslation(tag, translator, func(translator ut.Translator) error {
+			return translator.Add(tag, text, true)
+		}, func(translator ut.Translator, fieldErr validator.FieldError) string {
+			message, err := translator.T(fieldErr.Tag(), fieldErr.Field(), fieldErr.Param())
+			if err != nil {
+				return fieldErr.Error()
+			}
+
+			return message
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func FieldErrors(locale string, validationErr validator.ValidationErrors) []apperror.FieldError {
+	translator, ok := translators[Preferred(locale)]
+
+	fields := make([]apperror.FieldError, 0, len(validationErr))
+	for _, fieldErr := range validationErr {
+		message := fieldErr.Error()
+		if ok {
+			message = fieldErr.Translate(translator)
+		}
+
+		fields = append(fields, apperror.FieldError{
+			Field:   fieldErr.Field(),
+			Rule:    fieldErr.Tag(),
+			Param:   fieldErr.Param(),
+			Message: message,
+		})
+	}
+
+	return fields
+}
@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+type GormLogger struct {
+	SlowThreshold time.Duration
+	LogParams     bool
+	level         gormlogger.LogLevel
+}
+
+func NewGormLogger(slowThreshold time.Duration, logParams bool) *GormLogger {
+	return &GormLogger{
+		SlowThreshold: slowThreshold,
+		LogParams:     logParams,
+		level:         gormlogger.Info,
+	}
+}
+
+func (l *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	copied := *l
+	copied.level = level
+
+	return &copied
+}
+
+func (l *GormLogger) Info(ctx context.Context, message string, data ...interface{}) {
+	if l.level >= gormlogger.Info {
+		FromContext(ctx).Info().Str("component", "gorm").Msg(fmt.Sprintf(message, data...))
+	}
+}
+
+func (l *GormLogger) Warn(ctx context.Context, message string, data ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		FromContext(ctx).Warn().Str("component", "gorm").Msg(fmt.Sprintf(message, data...))
+	}
+}
+
+func (l *GormLogger) Error(ctx context.Context, message string, data ...interface{}) {
+	if l.level >= gormlogger.Error {
+		FromContext(ctx).Error().Str("component", "gorm").Msg(fmt.Sprintf(message, data...))
+	}
+}
+
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+
+	var event *zerolog.Event
+	message := "query"
+	log := FromContext(ctx)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= gormlogger.Error:
+		event = log.Error().Err(err)
+		message = "query failed"
+	case l.SlowThreshold > 0 && elapsed > l.SlowThreshold && l.level >= gormlogger.Warn:
+		event = log.Warn().Dur("threshold", l.SlowThreshold)
+		message = "slow query"
+	case l.level >= gormlogger.Info:
+		event = log.Debug()
+	default:
+		return
+	}
+	if !event.Enabled() {
+		return
+	}
+
+	sql, rows := fc()
+	event.Str("component", "gorm").
+		Dur("elapsed", elapsed).
+		Int64("rows", rows).
+		Str("sql", sql).
+		Msg(message)
+}
+
+func (l *GormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if l.LogParams {
+		return sql, params
+	}
+
+	return sql, nil
+}
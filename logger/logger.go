@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	stdlog "log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+type Config struct {
+	Level  string
+	Format string
+}
+
+var Log = zerolog.New(os.Stderr).With().Timestamp().Logger()
+
+func init() {
+	zerolog.TimeFieldFormat = time.RFC3339Nano
+	zerolog.DefaultContextLogger = &Log
+	redirectStdlib()
+}
+
+func Setup(config Config) error {
+	err := SetLevel(config.Level)
+	if err != nil {
+		return err
+	}
+
+	var output io.Writer = os.Stderr
+	switch config.Format {
+	case "", FormatJSON:
+	case FormatConsole:
+		output = zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.RFC3339}
+	default:
+		return fmt.Errorf("invalid log format %q", config.Format)
+	}
+
+	Log = zerolog.New(output).With().Timestamp().Logger()
+	redirectStdlib()
+
+	return nil
+}
+
+func ParseLevel(level string) (zerolog.Level, error) {
+	if level == "" {
+		return zerolog.InfoLevel, nil
+	}
+
+	parsed, err := zerolog.ParseLevel(strings.ToLower(level))
+	if err != nil || parsed == zerolog.NoLevel {
+		return parsed, fmt.Errorf("invalid log level %q", level)
+	}
+
+	return parsed, nil
+}
+
+// SetLevel changes the level of every logger, including ones already
+// attached to request contexts, so it is safe to call while serving.
+func SetLevel(level string) error {
+	parsed, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	zerolog.SetGlobalLevel(parsed)
+
+	return nil
+}
+
+func FromContext(ctx context.Context) *zerolog.Logger {
+	return zerolog.Ctx(ctx)
+}
+
+func redirectStdlib() {
+	stdlog.SetFlags(0)
+	stdlog.SetOutput(Log.With().Str("source", "stdlib").Logger())
+}
@@ -0,0 +1,49 @@
+package logger
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const RequestIDKey = "requestId"
+
+func Middleware() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		start := time.Now()
+
+		fields := Log.With().Str("request_id", g.GetString(RequestIDKey))
+		if spanContext := trace.SpanContextFromContext(g.Request.Context()); spanContext.HasTraceID() {
+			fields = fields.Str("trace_id", spanContext.TraceID().String())
+		}
+		log := fields.Logger()
+		g.Request = g.Request.WithContext(log.WithContext(g.Request.Context()))
+
+		g.Next()
+
+		params := map[string]string{}
+		for _, param := range g.Params {
+			params[param.Key] = param.Value
+		}
+
+		status := g.Writer.Status()
+		event := log.Info()
+		switch {
+		case status >= 500:
+			event = log.Error()
+		case status >= 400:
+			event = log.Warn()
+		}
+
+		event.Str("method", g.Request.Method).
+			Str("route", g.FullPath()).
+			Str("path", RedactPath(RedactURL(g.Request.URL), params)).
+			Int("status", status).
+			Dur("latency", time.Since(start)).
+			Str("client_ip", g.ClientIP()).
+			Str("user_agent", g.Request.UserAgent()).
+			Int("size", g.Writer.Size()).
+			Msg("request")
+	}
+}
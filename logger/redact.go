@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"net/url"
+	"strings"
+)
+
+const redacted = "[redacted]"
+
+var sensitiveKeys = []string{"password", "token", "secret", "authorization", "cookie"}
+
+func IsSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func Redact(key, value string) string {
+	if value != "" && IsSensitive(key) {
+		return redacted
+	}
+
+	return value
+}
+
+func RedactURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.Path
+	}
+
+	query := u.Query()
+	for key, values := range query {
+		for i := range values {
+			values[i] = Redact(key, values[i])
+		}
+		query[key] = values
+	}
+
+	return u.Path + "?" + strings.ReplaceAll(query.Encode(), url.QueryEscape(redacted), redacted)
+}
+
+func RedactPath(path string, params map[string]string) string {
+	for key, value := range params {
+		if value != "" && IsSensitive(key) {
+			path = strings.Replace(path, value, redacted, 1)
+		}
+	}
+
+	return path
+}
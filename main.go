@@ -1,41 +1,145 @@
 package main
 
 import (
-	"rakamin/controllers"
+	"flag"
+	"fmt"
+	"os"
 	"rakamin/database"
 	"rakamin/helpers"
-	"rakamin/middlewares"
-	"rakamin/models"
-	"rakamin/router"
+	"rakamin/logger"
 
-	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+const usage = `Usage: rakamin [--config path] <command> [arguments]
+
+Commands:
+  serve                          start the http server (default)
+  migrate                        apply database migrations
+  user create                    create a user, the password is prompted for or read from stdin
+  user disable <id|email>        disable a user and revoke their sessions
+  user enable <id|email>         enable a disabled user
+  user set-role <id|email> <role>
+  user reset-password <id|email> set a new password and revoke sessions
+  token issue <id|email>         issue an access token for debugging
+  storage gc                     remove image files no photo refers to
+  config check                   load and validate the configuration
+`
+
+var configPath string
+
 func main() {
-	configApp := helpers.GetConfig()
+	flag.StringVar(&configPath, "config", "", "path to the config file")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+	}
+	flag.Parse()
+
+	command, args := "serve", flag.Args()
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	var err error
+	switch command {
+	case "serve":
+		err = runServe(args)
+	case "migrate":
+		err = runMigrate(args)
+	case "user":
+		err = runUser(args)
+	case "token":
+		err = runToken(args)
+	case "storage":
+		err = runStorage(args)
+	case "config":
+		err = runConfig(args)
+	case "help":
+		flag.Usage()
+	default:
+		err = fmt.Errorf("unknown command %q, see rakamin help", command)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.StringVar(&configPath, "config", configPath, "path to the config file")
+
+	return flags
+}
+
+func openDB(configApp helpers.Config) (*gorm.DB, error) {
 	mysqlConfig := database.ConfigDB{
 		Username: configApp.Mysql.User,
 		Password: configApp.Mysql.Pass,
 		Host:     configApp.Mysql.Host,
 		Port:     configApp.Mysql.Port,
 		Database: configApp.Mysql.Name,
+		Logger:   logger.NewGormLogger(configApp.Logging.SlowQuery, configApp.Logging.SQLParams),
+	}
+
+	return mysqlConfig.ConfigDB()
+}
+
+// setupCommand loads the config and database for the one-shot commands, which
+// log to the console instead of the server's configured format.
+func setupCommand() (configApp helpers.Config, db *gorm.DB, err error) {
+	configApp, err = helpers.LoadConfig(configPath)
+	if err != nil {
+		return
+	}
+
+	err = logger.Setup(logger.Config{
+		Level:  configApp.Logging.Level,
+		Format: logger.FormatConsole,
+	})
+	if err != nil {
+		return
+	}
+
+	db, err = openDB(configApp)
+
+	return
+}
+
+func runMigrate(args []string) error {
+	flags := newFlagSet("migrate")
+	flags.Parse(args)
+
+	_, db, err := setupCommand()
+	if err != nil {
+		return err
 	}
-	mysqlDB := mysqlConfig.ConfigDB()
-	authMiddleware := middlewares.NewAuthorizationMiddleware(configApp.JWT.Secret, configApp.JWT.Expired)
 
-	userRepo := models.NewUserRepository(mysqlDB)
-	userController := controllers.NewUserController(userRepo, authMiddleware)
-	photoRepo := models.NewPhotoRepository(mysqlDB)
-	photoController := controllers.NewPhotoController(photoRepo, authMiddleware)
-	
-	r := gin.Default()
-	router := router.ControllerList{
-		AuthMiddleware:  authMiddleware,
-		UserController:  *userController,
-		PhotoController: *photoController,
+	err = database.Migrate(db)
+	if err != nil {
+		return err
 	}
-	
-	router.RouteRegister(r)
 
-	r.Run()
+	fmt.Println("migrations applied")
+
+	return nil
+}
+
+func runConfig(args []string) error {
+	if len(args) == 0 || args[0] != "check" {
+		return fmt.Errorf("usage: rakamin config check")
+	}
+
+	flags := newFlagSet("config check")
+	flags.Parse(args[1:])
+
+	configApp, err := helpers.LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("config ok (env %q)\n", configApp.Env)
+
+	return nil
 }
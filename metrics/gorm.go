@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const gormStartKey = "metrics:start"
+
+func InstrumentGORM(db *gorm.DB) error {
+	callback := db.Callback()
+
+	for _, err := range []error{
+		callback.Create().Before("gorm:create").Register("metrics:before_create", startTimer),
+		callback.Create().After("gorm:create").Register("metrics:after_create", observeDuration("create")),
+		callback.Query().Before("gorm:query").Register("metrics:before_query", startTimer),
+		callback.Query().After("gorm:query").Register("metrics:after_query", observeDuration("query")),
+		callback.Update().Before("gorm:update").Register("metrics:before_update", startTimer),
+		callback.Update().After("gorm:update").Register("metrics:after_update", observeDuration("update")),
+		callback.Delete().Before("gorm:delete").Register("metrics:before_delete", startTimer),
+		callback.Delete().After("gorm:delete").Register("metrics:after_delete", observeDuration("delete")),
+		callback.Row().Before("gorm:row").Register("metrics:before_row", startTimer),
+		callback.Row().After("gorm:row").Register("metrics:after_row", observeDuration("row")),
+		callback.Raw().Before("gorm:raw").Register("metrics:before_raw", startTimer),
+		callback.Raw().After("gorm:raw").Register("metrics:after_raw", observeDuration("raw")),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func startTimer(db *gorm.DB) {
+	db.InstanceSet(gormStartKey, time.Now())
+}
+
+func observeDuration(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(gormStartKey)
+		if !ok {
+			return
+		}
+
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+
+		DBQueryDuration.WithLabelValues(operation, table).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const Path = "/metrics"
+
+var (
+	DurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	SizeBuckets     = []float64{16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20, 16 << 20, 64 << 20}
+)
+
+// Registry is used instead of the prometheus default so only what is
+// registered here gets exposed.
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	HTTPRequests        = factory.NewCounterVec(prometheus.CounterOpts{Name: "http_requests_total", Help: "Total HTTP requests by route template and status."}, []string{"method", "route", "status"})
+	HTTPRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{Name: "http_request_duration_seconds", Help: "HTTP request latency by route template.", Buckets: DurationBuckets}, []string{"method", "route"})
+	UploadSize          = factory.NewHistogram(prometheus.HistogramOpts{Name: "photo_upload_size_bytes", Help: "Size of uploaded photo files.", Buckets: SizeBuckets})
+	UploadFileTypes     = factory.NewCounterVec(prometheus.CounterOpts{Name: "photo_upload_file_types_total", Help: "Uploaded files by detected content type and outcome."}, []string{"type", "result"})
+	LoginAttempts       = factory.NewCounterVec(prometheus.CounterOpts{Name: "login_attempts_total", Help: "Login attempts by outcome."}, []string{"result"})
+	DBQueryDuration     = factory.NewHistogramVec(prometheus.HistogramOpts{Name: "db_query_duration_seconds", Help: "GORM statement latency by operation and table.", Buckets: DurationBuckets}, []string{"operation", "table"})
+	RateLimited         = factory.NewCounterVec(prometheus.CounterOpts{Name: "rate_limited_requests_total", Help: "Requests rejected by the rate limiter by policy."}, []string{"policy"})
+	ImageTransforms     = factory.NewCounterVec(prometheus.CounterOpts{Name: "image_transforms_total", Help: "Image transform requests by result cache outcome."}, []string{"cache"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+func Middleware() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		start := time.Now()
+
+		g.Next()
+
+		route := g.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		HTTPRequests.WithLabelValues(g.Request.Method, route, strconv.Itoa(g.Writer.Status())).Inc()
+		HTTPRequestDuration.WithLabelValues(g.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+func Handler(token string) http.Handler {
+	handler := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func RegisterDBStats(db *sql.DB) {
+	Registry.MustRegister(&dbStatsCollector{db: db})
+}
+
+func RegisterJobQueueDepth(countByStatus func() (map[string]int64, error)) {
+	Registry.MustRegister(&jobQueueCollector{countByStatus: countByStatus})
+}
+
+var (
+	dbPoolConnections  = prometheus.NewDesc("db_pool_connections", "Database pool connections by state.", []string{"state"}, nil)
+	dbPoolWaitCount    = prometheus.NewDesc("db_pool_wait_count_total", "Total connections waited for.", nil, nil)
+	dbPoolWaitDuration = prometheus.NewDesc("db_pool_wait_duration_seconds_total", "Total time blocked waiting for a connection.", nil, nil)
+	jobQueueDepth      = prometheus.NewDesc("job_queue_depth", "Background jobs by status.", []string{"status"}, nil)
+)
+
+type dbStatsCollector struct {
+	db *sql.DB
+}
+
+func (c *dbStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- dbPoolConnections
+	ch <- dbPoolWaitCount
+	ch <- dbPoolWaitDuration
+}
+
+func (c *dbStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.db.Stats()
+
+	ch <- prometheus.MustNewConstMetric(dbPoolConnections, prometheus.GaugeValue, float64(stats.OpenConnections), "open")
+	ch <- prometheus.MustNewConstMetric(dbPoolConnections, prometheus.GaugeValue, float64(stats.InUse), "in_use")
+	ch <- prometheus.MustNewConstMetric(dbPoolConnections, prometheus.GaugeValue, float64(stats.Idle), "idle")
+	ch <- prometheus.MustNewConstMetric(dbPoolConnections, prometheus.GaugeValue, float64(stats.MaxOpenConnections), "max_open")
+	ch <- prometheus.MustNewConstMetric(dbPoolWaitCount, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(dbPoolWaitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds())
+}
+
+type jobQueueCollector struct {
+	countByStatus func() (map[string]int64, error)
+}
+
+func (c *jobQueueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- jobQueueDepth
+}
+
+func (c *jobQueueCollector) Collect(ch chan<- prometheus.Metric) {
+	counts, err := c.countByStatus()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(jobQueueDepth, err)
+		return
+	}
+
+	for status, count := range counts {
+		ch <- prometheus.MustNewConstMetric(jobQueueDepth, prometheus.GaugeValue, float64(count), status)
+	}
+}
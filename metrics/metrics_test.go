@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type nopConnector struct{}
+
+func (nopConnector) Connect(context.Context) (driver.Conn, error) {
+	return nil, errors.New("not connected")
+}
+
+func (nopConnector) Driver() driver.Driver {
+	return nil
+}
+
+func TestHandler(t *testing.T) {
+	db := sql.OpenDB(nopConnector{})
+	defer db.Close()
+	RegisterDBStats(db)
+	RegisterJobQueueDepth(func() (map[string]int64, error) {
+		return map[string]int64{"pending": 3}, nil
+	})
+	HTTPRequests.WithLabelValues("GET", "/api/v1/photos", "200").Inc()
+
+	w := httptest.NewRecorder()
+	Handler("").ServeHTTP(w, httptest.NewRequest(http.MethodGet, Path, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d", w.Code)
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{
+		"# TYPE go_goroutines gauge",
+		"# TYPE process_cpu_seconds_total counter",
+		"# TYPE db_pool_wait_count_total counter",
+		"# TYPE db_pool_wait_duration_seconds_total counter",
+		`db_pool_connections{state="max_open"} 0`,
+		`job_queue_depth{status="pending"} 3`,
+		`http_requests_total{method="GET",route="/api/v1/photos",status="200"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("exposition is missing %q", want)
+		}
+	}
+}
+
+func TestHandlerRequiresToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{"missing", "", http.StatusUnauthorized},
+		{"wrong", "Bearer nope", http.StatusUnauthorized},
+		{"valid", "Bearer secret", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, Path, nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+
+			w := httptest.NewRecorder()
+			Handler("secret").ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Errorf("status %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package middlewares
+
+import (
+	"rakamin/apperror"
+	"rakamin/helpers"
+	"rakamin/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminMiddleware struct {
+	userRepo       models.UserRepository
+	AuthMiddleware *AuthorizationMiddleware
+}
+
+func NewAdminMiddleware(userRepo models.UserRepository, authMiddleware *AuthorizationMiddleware) *AdminMiddleware {
+	return &AdminMiddleware{
+		userRepo:       userRepo,
+		AuthMiddleware: authMiddleware,
+	}
+}
+
+func (a *AdminMiddleware) Admin() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		id, err := a.AuthMiddleware.GetUserId(g)
+		if err != nil {
+			helpers.AbortWithError(g, err)
+
+			return
+		}
+
+		user, err := a.userRepo.WithContext(g.Request.Context()).GetById(id)
+		if err != nil || user.Role != models.RoleAdmin {
+			helpers.AbortWithError(g, apperror.ErrForbidden)
+
+			return
+		}
+	}
+}
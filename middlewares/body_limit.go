@@ -0,0 +1,27 @@
+package middlewares
+
+import (
+	"net/http"
+	"rakamin/apperror"
+	"rakamin/helpers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimit rejects requests whose declared length exceeds maxBytes and caps
+// the body of the rest, so oversize uploads fail before they are buffered.
+func BodyLimit(maxBytes int64) gin.HandlerFunc {
+	return func(g *gin.Context) {
+		if maxBytes <= 0 {
+			return
+		}
+
+		if g.Request.ContentLength > maxBytes {
+			helpers.AbortWithError(g, apperror.ErrRequestTooLarge)
+
+			return
+		}
+
+		g.Request.Body = http.MaxBytesReader(g.Writer, g.Request.Body, maxBytes)
+	}
+}
@@ -0,0 +1,39 @@
+package middlewares
+
+import (
+	"rakamin/i18n"
+	"rakamin/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LocaleMiddleware struct {
+	userRepo       models.UserRepository
+	AuthMiddleware *AuthorizationMiddleware
+}
+
+func NewLocaleMiddleware(userRepo models.UserRepository, authMiddleware *AuthorizationMiddleware) *LocaleMiddleware {
+	return &LocaleMiddleware{
+		userRepo:       userRepo,
+		AuthMiddleware: authMiddleware,
+	}
+}
+
+func (l *LocaleMiddleware) Locale() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		locale := i18n.Negotiate(g.GetHeader("Accept-Language"))
+
+		if tokenFromRequest(g) != "" {
+			id, err := l.AuthMiddleware.GetUserId(g)
+			if err == nil {
+				user, err := l.userRepo.WithContext(g.Request.Context()).GetById(id)
+				if err == nil && i18n.IsSupported(user.Locale) {
+					locale = user.Locale
+				}
+			}
+		}
+
+		g.Set(i18n.ContextKey, locale)
+		g.Header("Content-Language", locale)
+	}
+}
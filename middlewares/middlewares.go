@@ -3,62 +3,105 @@ package middlewares
 import (
 	"errors"
 	"fmt"
-	"net/http"
+	"rakamin/apperror"
 	"rakamin/helpers"
+	"rakamin/logger"
+	"rakamin/models"
 	"strconv"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	sessionTouchInterval = time.Minute
+	queryTokenKey        = "allowQueryToken"
 )
 
 type JwtCustomClaims struct {
-	ID int `json:"id"`
+	ID        int    `json:"id"`
+	SessionID string `json:"sid"`
 	jwt.StandardClaims
 }
 
 type AuthorizationMiddleware struct {
 	jwtSecret       string
-	ExpiresDuration int
+	ExpiresDuration time.Duration
+	sessionRepo     models.SessionRepository
 }
 
-func NewAuthorizationMiddleware(jwtSecret string, expired int) *AuthorizationMiddleware {
+func NewAuthorizationMiddleware(jwtSecret string, expired time.Duration, sessionRepo models.SessionRepository) *AuthorizationMiddleware {
 	return &AuthorizationMiddleware{
 		jwtSecret:       jwtSecret,
 		ExpiresDuration: expired,
+		sessionRepo:     sessionRepo,
 	}
 }
 
 func (a *AuthorizationMiddleware) Authorization() gin.HandlerFunc {
 	return func(g *gin.Context) {
-		authHeader := g.GetHeader("Authorization")
+		authHeader := tokenFromRequest(g)
 		if authHeader == "" {
-			response := helpers.NewErrorResponse(errors.New("token tidak ditemukan"))
-			g.JSON(http.StatusUnauthorized, response)
+			helpers.AbortWithError(g, apperror.ErrTokenMissing)
+
+			return
+		}
+		t, err := a.ValidateToken(authHeader)
+		if err != nil {
+			helpers.AbortWithError(g, apperror.ErrTokenInvalid)
+
+			return
+		}
+
+		sessionRepo := a.sessionRepo.WithContext(g.Request.Context())
+		sessionId := sessionIdFromToken(t)
+		session, err := sessionRepo.GetById(sessionId)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && session.RevokedAt != nil) {
+			helpers.AbortWithError(g, apperror.ErrSessionRevoked)
 
 			return
 		}
-		_, err := a.ValidateToken(authHeader)
 		if err != nil {
-			response := helpers.NewErrorResponse(errors.New("token tidak valid"))
-			g.JSON(http.StatusUnauthorized, response)
+			helpers.AbortWithError(g, err)
 
 			return
 		}
+
+		// last_seen_at only feeds the session list, so a write per request
+		// isn't worth it.
+		if session.LastSeenAt == nil || time.Since(*session.LastSeenAt) > sessionTouchInterval {
+			err = sessionRepo.Touch(sessionId)
+			if err != nil {
+				logger.FromContext(g.Request.Context()).Warn().Err(err).Str("sessionId", sessionId).Msg("touching session")
+			}
+		}
 	}
 }
 
-func (a *AuthorizationMiddleware) GenerateToken(userID int) string {
+// AllowQueryToken lets the routes after it read the token from the
+// access_token query parameter, for clients such as EventSource and browser
+// WebSockets that can't set headers. Anywhere else it would leak tokens into
+// access logs and Referer headers.
+func (a *AuthorizationMiddleware) AllowQueryToken() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		g.Set(queryTokenKey, true)
+	}
+}
+
+func (a *AuthorizationMiddleware) GenerateToken(userID int, sessionID string) string {
 	claims := &JwtCustomClaims{
 		userID,
+		sessionID,
 		jwt.StandardClaims{
-			ExpiresAt: time.Now().Local().Add(time.Hour * time.Duration(int64(a.ExpiresDuration))).Unix(),
+			ExpiresAt: time.Now().Local().Add(a.ExpiresDuration).Unix(),
 		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	t, err := token.SignedString([]byte(a.jwtSecret))
 	if err != nil {
-		fmt.Println("error signed token :", err)
+		logger.Log.Error().Err(err).Msg("signing token")
 	}
 	return t
 }
@@ -73,14 +116,16 @@ func (a *AuthorizationMiddleware) ValidateToken(token string) (*jwt.Token, error
 }
 
 func (a *AuthorizationMiddleware) GetUserId(g *gin.Context) (id int, err error) {
-	token := g.Request.Header.Get("Authorization")
+	token := tokenFromRequest(g)
 	t, err := a.ValidateToken(token)
 	if err != nil {
+		err = apperror.ErrTokenInvalid.Wrap(err)
 		return
 	}
 
 	claims, valid := t.Claims.(jwt.MapClaims)
 	if !valid {
+		err = apperror.ErrTokenInvalid
 		return
 	}
 
@@ -88,3 +133,36 @@ func (a *AuthorizationMiddleware) GetUserId(g *gin.Context) (id int, err error)
 
 	return
 }
+
+func (a *AuthorizationMiddleware) GetSessionId(g *gin.Context) (id string, err error) {
+	token := tokenFromRequest(g)
+	t, err := a.ValidateToken(token)
+	if err != nil {
+		err = apperror.ErrTokenInvalid.Wrap(err)
+		return
+	}
+
+	id = sessionIdFromToken(t)
+
+	return
+}
+
+func tokenFromRequest(g *gin.Context) string {
+	token := g.GetHeader("Authorization")
+	if token == "" && g.GetBool(queryTokenKey) {
+		token = g.Query("access_token")
+	}
+
+	return token
+}
+
+func sessionIdFromToken(t *jwt.Token) string {
+	claims, valid := t.Claims.(jwt.MapClaims)
+	if !valid {
+		return ""
+	}
+
+	sid, _ := claims["sid"].(string)
+
+	return sid
+}
@@ -0,0 +1,161 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"rakamin/models"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type fakeSessionRepo struct {
+	models.SessionRepository
+	session  models.Session
+	getErr   error
+	touched  int
+	touchErr error
+}
+
+func (r *fakeSessionRepo) WithContext(ctx context.Context) models.SessionRepository {
+	return r
+}
+
+func (r *fakeSessionRepo) GetById(id string) (models.Session, error) {
+	return r.session, r.getErr
+}
+
+func (r *fakeSessionRepo) Touch(id string) error {
+	r.touched++
+
+	return r.touchErr
+}
+
+func TestAuthorizationTouchesSessionAtMostOncePerInterval(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recent := time.Now().Add(-10 * time.Second)
+	stale := time.Now().Add(-2 * sessionTouchInterval)
+	revoked := time.Now()
+
+	tests := []struct {
+		name     string
+		session  models.Session
+		touchErr error
+		status   int
+		touched  int
+	}{
+		{"never seen", models.Session{ID: "s"}, nil, http.StatusOK, 1},
+		{"seen recently", models.Session{ID: "s", LastSeenAt: &recent}, nil, http.StatusOK, 0},
+		{"seen a while ago", models.Session{ID: "s", LastSeenAt: &stale}, nil, http.StatusOK, 1},
+		{"touch fails", models.Session{ID: "s", LastSeenAt: &stale}, errors.New("db down"), http.StatusOK, 1},
+		{"revoked", models.Session{ID: "s", RevokedAt: &revoked}, nil, http.StatusUnauthorized, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeSessionRepo{session: tt.session, touchErr: tt.touchErr}
+			auth := NewAuthorizationMiddleware("secret", time.Hour, repo)
+
+			g := gin.New()
+			g.GET("/", auth.Authorization(), func(g *gin.Context) {
+				g.Status(http.StatusOK)
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Authorization", auth.GenerateToken(1, "s"))
+			w := httptest.NewRecorder()
+			g.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Errorf("status %d, want %d", w.Code, tt.status)
+			}
+			if repo.touched != tt.touched {
+				t.Errorf("touched %d times, want %d", repo.touched, tt.touched)
+			}
+		})
+	}
+}
+
+func TestAuthorizationSessionLookup(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	revoked := time.Now()
+
+	tests := []struct {
+		name    string
+		session models.Session
+		getErr  error
+		status  int
+	}{
+		{"active", models.Session{ID: "s"}, nil, http.StatusOK},
+		{"revoked", models.Session{ID: "s", RevokedAt: &revoked}, nil, http.StatusUnauthorized},
+		{"missing", models.Session{}, gorm.ErrRecordNotFound, http.StatusUnauthorized},
+		{"lookup fails", models.Session{}, errors.New("db down"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := NewAuthorizationMiddleware("secret", time.Hour, &fakeSessionRepo{session: tt.session, getErr: tt.getErr})
+
+			g := gin.New()
+			g.GET("/", auth.Authorization(), func(g *gin.Context) {
+				g.Status(http.StatusOK)
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Authorization", auth.GenerateToken(1, "s"))
+			w := httptest.NewRecorder()
+			g.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Errorf("status %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestAuthorizationQueryToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	auth := NewAuthorizationMiddleware("secret", time.Hour, &fakeSessionRepo{session: models.Session{ID: "s"}})
+	token := auth.GenerateToken(1, "s")
+
+	g := gin.New()
+	handler := func(g *gin.Context) {
+		g.Status(http.StatusOK)
+	}
+	g.GET("/api", auth.Authorization(), handler)
+	g.GET("/events", auth.AllowQueryToken(), auth.Authorization(), handler)
+
+	tests := []struct {
+		name   string
+		target string
+		header bool
+		status int
+	}{
+		{"header on api", "/api", true, http.StatusOK},
+		{"query on api", "/api?access_token=" + token, false, http.StatusUnauthorized},
+		{"header on events", "/events", true, http.StatusOK},
+		{"query on events", "/events?access_token=" + token, false, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.header {
+				r.Header.Set("Authorization", token)
+			}
+			w := httptest.NewRecorder()
+			g.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Errorf("status %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
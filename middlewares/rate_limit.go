@@ -0,0 +1,89 @@
+package middlewares
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"rakamin/apperror"
+	"rakamin/helpers"
+	"rakamin/logger"
+	"rakamin/metrics"
+	"rakamin/ratelimit"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RateLimitMiddleware struct {
+	store          ratelimit.Store
+	policies       atomic.Value
+	AuthMiddleware *AuthorizationMiddleware
+	Now            func() time.Time
+}
+
+func NewRateLimitMiddleware(store ratelimit.Store, policies map[string]ratelimit.Policy, authMiddleware *AuthorizationMiddleware) *RateLimitMiddleware {
+	middleware := &RateLimitMiddleware{
+		store:          store,
+		AuthMiddleware: authMiddleware,
+		Now:            time.Now,
+	}
+	middleware.SetPolicies(policies)
+
+	return middleware
+}
+
+func (r *RateLimitMiddleware) SetPolicies(policies map[string]ratelimit.Policy) {
+	r.policies.Store(policies)
+}
+
+// Limit throttles the route with the named policy. Policies are looked up on
+// every request so reloaded limits apply without re-registering routes.
+func (r *RateLimitMiddleware) Limit(name string) gin.HandlerFunc {
+	return func(g *gin.Context) {
+		policy, ok := r.policies.Load().(map[string]ratelimit.Policy)[name]
+		if !ok || !policy.Enabled() {
+			return
+		}
+
+		key := name + ":" + r.identity(g, policy.By)
+		result, err := r.store.Take(g.Request.Context(), key, policy, r.Now())
+		if err != nil {
+			logger.FromContext(g.Request.Context()).Error().Err(err).Str("policy", name).Msg("rate limiter unavailable, allowing request")
+
+			return
+		}
+
+		g.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		g.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		g.Header("RateLimit-Reset", strconv.Itoa(int(result.Reset.Seconds())))
+		g.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Requests, int(policy.Period.Seconds())))
+
+		if !result.Allowed {
+			metrics.RateLimited.WithLabelValues(name).Inc()
+			g.Header("Retry-After", strconv.Itoa(int(result.RetryAfter.Seconds())))
+			helpers.AbortWithError(g, apperror.ErrRateLimited)
+
+			return
+		}
+	}
+}
+
+func (r *RateLimitMiddleware) identity(g *gin.Context, by string) string {
+	switch by {
+	case ratelimit.ByUser:
+		id, err := r.AuthMiddleware.GetUserId(g)
+		if err == nil {
+			return "user:" + strconv.Itoa(id)
+		}
+	case ratelimit.ByToken:
+		token := tokenFromRequest(g)
+		if token != "" {
+			sum := sha256.Sum256([]byte(token))
+			return "token:" + hex.EncodeToString(sum[:])
+		}
+	}
+
+	return "ip:" + g.ClientIP()
+}
@@ -0,0 +1,65 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"rakamin/ratelimit"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	start := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		route      string
+		at         time.Duration
+		status     int
+		remaining  string
+		retryAfter string
+	}{
+		{"first", "/strict", 0, http.StatusOK, "1", ""},
+		{"second", "/strict", 0, http.StatusOK, "0", ""},
+		{"limited", "/strict", 30 * time.Second, http.StatusTooManyRequests, "0", "30"},
+		{"other policy", "/loose", 10 * time.Second, http.StatusOK, "4", ""},
+		{"unknown policy", "/unlimited", 10 * time.Second, http.StatusOK, "", ""},
+		{"refilled", "/strict", 90 * time.Second, http.StatusOK, "0", ""},
+	}
+
+	now := start
+	limiter := NewRateLimitMiddleware(ratelimit.NewMemoryStore(), map[string]ratelimit.Policy{
+		"strict": {Requests: 2, Period: 2 * time.Minute, By: ratelimit.ByIP},
+		"loose":  {Requests: 5, Period: time.Minute, By: ratelimit.ByIP},
+	}, nil)
+	limiter.Now = func() time.Time { return now }
+
+	g := gin.New()
+	handler := func(g *gin.Context) {
+		g.Status(http.StatusOK)
+	}
+	g.GET("/strict", limiter.Limit("strict"), handler)
+	g.GET("/loose", limiter.Limit("loose"), handler)
+	g.GET("/unlimited", limiter.Limit("missing"), handler)
+
+	for _, tt := range tests {
+		now = start.Add(tt.at)
+
+		w := httptest.NewRecorder()
+		g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.route, nil))
+
+		if w.Code != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.name, w.Code, tt.status)
+		}
+		if got := w.Header().Get("RateLimit-Remaining"); got != tt.remaining {
+			t.Errorf("%s: RateLimit-Remaining %q, want %q", tt.name, got, tt.remaining)
+		}
+		if got := w.Header().Get("Retry-After"); got != tt.retryAfter {
+			t.Errorf("%s: Retry-After %q, want %q", tt.name, got, tt.retryAfter)
+		}
+	}
+}
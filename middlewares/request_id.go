@@ -0,0 +1,25 @@
+package middlewares
+
+import (
+	"rakamin/helpers"
+	"rakamin/logger"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+var requestIdPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+func RequestID() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		requestId := g.GetHeader(RequestIDHeader)
+		if !requestIdPattern.MatchString(requestId) {
+			requestId = helpers.GetUUID()
+		}
+
+		g.Set(logger.RequestIDKey, requestId)
+		g.Header(RequestIDHeader, requestId)
+	}
+}
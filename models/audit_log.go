@@ -0,0 +1,103 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AuditLog struct {
+	ID         int        `gorm:"primaryKey"`
+	ActorID    int        `gorm:"not null;index"`
+	Actor      string     `gorm:"size:64;not null;default:''"`
+	Action     string     `gorm:"not null;index"`
+	TargetType string     `gorm:"not null"`
+	TargetID   string     `gorm:"not null"`
+	IP         string     `gorm:"not null"`
+	UserAgent  string     `gorm:"not null"`
+	RequestID  string     `gorm:"not null"`
+	Changes    string     `gorm:"type:text"`
+	CreatedAt  *time.Time `gorm:"default:CURRENT_TIMESTAMP;index"`
+}
+
+type AuditLogFilter struct {
+	ActorID    int
+	Action     string
+	TargetType string
+	TargetID   string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+type AuditLogDBConnectionRepository struct {
+	Conn *gorm.DB
+}
+
+type AuditLogRepository interface {
+	Insert(log AuditLog) (err error)
+	GetAllByUserId(userId int, limit int) (logs []AuditLog, err error)
+	Find(filter AuditLogFilter) (logs []AuditLog, total int64, err error)
+	DeleteOlderThan(before time.Time) (deleted int64, err error)
+}
+
+func NewAuditLogRepository(conn *gorm.DB) AuditLogRepository {
+	return &AuditLogDBConnectionRepository{
+		Conn: conn,
+	}
+}
+
+func (repository *AuditLogDBConnectionRepository) Insert(log AuditLog) (err error) {
+	err = repository.Conn.Create(&log).Error
+
+	return
+}
+
+func (repository *AuditLogDBConnectionRepository) GetAllByUserId(userId int, limit int) (logs []AuditLog, err error) {
+	err = repository.Conn.
+		Where("actor_id = ? OR (target_type = ? AND target_id = ?)", userId, "user", userId).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&logs).Error
+
+	return
+}
+
+func (repository *AuditLogDBConnectionRepository) Find(filter AuditLogFilter) (logs []AuditLog, total int64, err error) {
+	query := repository.Conn.Model(&AuditLog{})
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", filter.To)
+	}
+
+	err = query.Count(&total).Error
+	if err != nil {
+		return
+	}
+
+	err = query.Order("created_at DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&logs).Error
+
+	return
+}
+
+func (repository *AuditLogDBConnectionRepository) DeleteOlderThan(before time.Time) (deleted int64, err error) {
+	result := repository.Conn.Where("created_at < ?", before).Delete(&AuditLog{})
+	deleted, err = result.RowsAffected, result.Error
+
+	return
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ExportStatusPending    = "pending"
+	ExportStatusProcessing = "processing"
+	ExportStatusReady      = "ready"
+	ExportStatusFailed     = "failed"
+)
+
+type DataExport struct {
+	ID          string `gorm:"primaryKey;size:36"`
+	UserID      int    `gorm:"not null;index"`
+	Status      string `gorm:"not null;default:pending"`
+	FilePath    string
+	TokenHash   string `gorm:"column:token;size:64"`
+	Error       string
+	ExpiresAt   *time.Time
+	CompletedAt *time.Time
+	CreatedAt   *time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	User        *User
+}
+
+type DataExportDBConnectionRepository struct {
+	Conn *gorm.DB
+}
+
+type DataExportRepository interface {
+	Insert(export DataExport) (err error)
+	GetById(userId int, id string) (export DataExport, err error)
+	GetReadyById(id string) (export DataExport, err error)
+	Update(export DataExport) (err error)
+	GetAllExpiredBefore(before time.Time) (exports []DataExport, err error)
+	DeleteById(id string) (err error)
+}
+
+func NewDataExportRepository(conn *gorm.DB) DataExportRepository {
+	return &DataExportDBConnectionRepository{
+		Conn: conn,
+	}
+}
+
+func (repository *DataExportDBConnectionRepository) Insert(export DataExport) (err error) {
+	err = repository.Conn.Create(&export).Error
+
+	return
+}
+
+func (repository *DataExportDBConnectionRepository) GetById(userId int, id string) (export DataExport, err error) {
+	err = repository.Conn.Where("id = ? AND user_id = ?", id, userId).First(&export).Error
+
+	return
+}
+
+func (repository *DataExportDBConnectionRepository) GetReadyById(id string) (export DataExport, err error) {
+	err = repository.Conn.Where("id = ? AND status = ?", id, ExportStatusReady).First(&export).Error
+
+	return
+}
+
+func (repository *DataExportDBConnectionRepository) Update(export DataExport) (err error) {
+	err = repository.Conn.Where("id = ?", export.ID).Updates(&export).Error
+
+	return
+}
+
+func (repository *DataExportDBConnectionRepository) GetAllExpiredBefore(before time.Time) (exports []DataExport, err error) {
+	err = repository.Conn.Where("expires_at IS NOT NULL AND expires_at < ?", before).Find(&exports).Error
+
+	return
+}
+
+func (repository *DataExportDBConnectionRepository) DeleteById(id string) (err error) {
+	err = repository.Conn.Where("id = ?", id).Delete(&DataExport{}).Error
+
+	return
+}
@@ -0,0 +1,217 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusDead      = "dead"
+)
+
+type Job struct {
+	ID          int       `gorm:"primaryKey"`
+	Type        string    `gorm:"not null;index"`
+	Payload     string    `gorm:"type:text"`
+	Status      string    `gorm:"not null;default:queued;index:idx_job_status_run_at"`
+	Attempts    int       `gorm:"not null;default:0"`
+	MaxAttempts int       `gorm:"not null"`
+	LastError   string    `gorm:"type:text"`
+	RunAt       time.Time `gorm:"not null;index:idx_job_status_run_at"`
+	LockedAt    *time.Time
+	LockedBy    string
+	HeartbeatAt *time.Time `gorm:"index"`
+	CreatedAt   *time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt   *time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+type JobFilter struct {
+	Type   string
+	Status string
+	Limit  int
+	Offset int
+}
+
+type JobDBConnectionRepository struct {
+	Conn *gorm.DB
+}
+
+type JobRepository interface {
+	Insert(job Job) (id int, err error)
+	GetById(id int) (job Job, err error)
+	Find(filter JobFilter) (jobs []Job, total int64, err error)
+	CountByStatus() (counts map[string]int64, err error)
+	ClaimNext(worker string, now time.Time) (job Job, err error)
+	Heartbeat(id int, worker string, now time.Time) (err error)
+	MarkSucceeded(id int) (err error)
+	MarkRetry(id int, lastError string, runAt time.Time) (err error)
+	MarkDead(id int, lastError string) (err error)
+	Retry(id int) (err error)
+	ReleaseStale(before time.Time) (released int64, err error)
+}
+
+func NewJobRepository(conn *gorm.DB) JobRepository {
+	return &JobDBConnectionRepository{
+		Conn: conn,
+	}
+}
+
+func (repository *JobDBConnectionRepository) Insert(job Job) (id int, err error) {
+	err = repository.Conn.Create(&job).Error
+	id = job.ID
+
+	return
+}
+
+func (repository *JobDBConnectionRepository) GetById(id int) (job Job, err error) {
+	err = repository.Conn.Where("id = ?", id).First(&job).Error
+
+	return
+}
+
+func (repository *JobDBConnectionRepository) Find(filter JobFilter) (jobs []Job, total int64, err error) {
+	query := repository.Conn.Model(&Job{})
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	err = query.Count(&total).Error
+	if err != nil {
+		return
+	}
+
+	err = query.Order("id DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&jobs).Error
+
+	return
+}
+
+func (repository *JobDBConnectionRepository) CountByStatus() (counts map[string]int64, err error) {
+	var rows []struct {
+		Status string
+		Total  int64
+	}
+
+	err = repository.Conn.Model(&Job{}).Select("status, COUNT(*) AS total").Group("status").Scan(&rows).Error
+	if err != nil {
+		return
+	}
+
+	counts = map[string]int64{}
+	for _, row := range rows {
+		counts[row.Status] = row.Total
+	}
+
+	return
+}
+
+func (repository *JobDBConnectionRepository) ClaimNext(worker string, now time.Time) (job Job, err error) {
+	err = repository.Conn.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND run_at <= ?", JobStatusQueued, now).
+			Order("run_at").
+			First(&job).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&Job{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+			"status":       JobStatusRunning,
+			"attempts":     gorm.Expr("attempts + 1"),
+			"locked_at":    now,
+			"locked_by":    worker,
+			"heartbeat_at": now,
+		}).Error
+	})
+	if err == nil {
+		job.Status = JobStatusRunning
+		job.Attempts++
+	}
+
+	return
+}
+
+// Heartbeat marks a running job as still owned by worker. It fails with
+// gorm.ErrRecordNotFound once the job was released to another worker.
+func (repository *JobDBConnectionRepository) Heartbeat(id int, worker string, now time.Time) (err error) {
+	result := repository.Conn.Model(&Job{}).
+		Where("id = ? AND status = ? AND locked_by = ?", id, JobStatusRunning, worker).
+		Update("heartbeat_at", now)
+	err = result.Error
+	if err == nil && result.RowsAffected == 0 {
+		err = gorm.ErrRecordNotFound
+	}
+
+	return
+}
+
+func (repository *JobDBConnectionRepository) MarkSucceeded(id int) (err error) {
+	err = repository.Conn.Model(&Job{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       JobStatusSucceeded,
+		"locked_at":    nil,
+		"locked_by":    "",
+		"heartbeat_at": nil,
+	}).Error
+
+	return
+}
+
+func (repository *JobDBConnectionRepository) MarkRetry(id int, lastError string, runAt time.Time) (err error) {
+	err = repository.Conn.Model(&Job{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       JobStatusQueued,
+		"last_error":   lastError,
+		"run_at":       runAt,
+		"locked_at":    nil,
+		"locked_by":    "",
+		"heartbeat_at": nil,
+	}).Error
+
+	return
+}
+
+func (repository *JobDBConnectionRepository) MarkDead(id int, lastError string) (err error) {
+	err = repository.Conn.Model(&Job{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       JobStatusDead,
+		"last_error":   lastError,
+		"locked_at":    nil,
+		"locked_by":    "",
+		"heartbeat_at": nil,
+	}).Error
+
+	return
+}
+
+func (repository *JobDBConnectionRepository) Retry(id int) (err error) {
+	result := repository.Conn.Model(&Job{}).Where("id = ? AND status IN ?", id, []string{JobStatusDead, JobStatusQueued}).Updates(map[string]interface{}{
+		"status":   JobStatusQueued,
+		"attempts": 0,
+		"run_at":   time.Now(),
+	})
+	err = result.Error
+	if err == nil && result.RowsAffected == 0 {
+		err = gorm.ErrRecordNotFound
+	}
+
+	return
+}
+
+// ReleaseStale requeues running jobs whose worker stopped sending heartbeats,
+// whichever replica that worker belonged to.
+func (repository *JobDBConnectionRepository) ReleaseStale(before time.Time) (released int64, err error) {
+	result := repository.Conn.Model(&Job{}).Where("status = ? AND COALESCE(heartbeat_at, locked_at) < ?", JobStatusRunning, before).Updates(map[string]interface{}{
+		"status":       JobStatusQueued,
+		"locked_at":    nil,
+		"locked_by":    "",
+		"heartbeat_at": nil,
+	})
+	released, err = result.RowsAffected, result.Error
+
+	return
+}
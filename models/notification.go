@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Notification struct {
+	ID        int    `gorm:"primaryKey"`
+	UserID    int    `gorm:"not null;index"`
+	Title     string `gorm:"not null"`
+	Message   string `gorm:"not null"`
+	ReadAt    *time.Time
+	CreatedAt *time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	User      *User
+}
+
+type NotificationDBConnectionRepository struct {
+	Conn *gorm.DB
+}
+
+type NotificationRepository interface {
+	Insert(notification Notification) (err error)
+	GetAllByUserId(userId int) (notifications []Notification, err error)
+}
+
+func NewNotificationRepository(conn *gorm.DB) NotificationRepository {
+	return &NotificationDBConnectionRepository{
+		Conn: conn,
+	}
+}
+
+func (repository *NotificationDBConnectionRepository) Insert(notification Notification) (err error) {
+	err = repository.Conn.Create(&notification).Error
+
+	return
+}
+
+func (repository *NotificationDBConnectionRepository) GetAllByUserId(userId int) (notifications []Notification, err error) {
+	err = repository.Conn.Where("user_id = ?", userId).Order("created_at DESC").Find(&notifications).Error
+
+	return
+}
@@ -1,25 +1,63 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"context"
+	"errors"
+	"rakamin/apperror"
+	"rakamin/helpers"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Photo struct {
-	ID       int    `gorm:"primary_key;auto_increment"`
-	Title    string `gorm:"not null"`
-	Caption  string `gorm:"not null"`
-	PhotoURL string `gorm:"not null"`
-	UserID   int    `gorm:"not null"`
-	User     *User
+	ID           int    `gorm:"primary_key;auto_increment"`
+	Title        string `gorm:"not null"`
+	Caption      string `gorm:"not null"`
+	PhotoURL     string `gorm:"size:255;not null;index"`
+	OriginalURL  string `gorm:"size:255;index"`
+	Tags         string
+	Album        string `gorm:"index"`
+	Status       string `gorm:"not null;default:ready"`
+	Hash         string `gorm:"size:64;index"`
+	Size         int64
+	VariantBytes int64 `gorm:"not null;default:0"`
+	Width        int
+	Height       int
+	UserID       int `gorm:"not null"`
+	User         *User
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
 }
 
+const (
+	PhotoStatusPending = "pending"
+	PhotoStatusReady   = "ready"
+	PhotoStatusFailed  = "failed"
+)
+
 type PhotoDBConnectionRepository struct {
 	Conn *gorm.DB
 }
 
 type PhotoRepository interface {
-	Insert(photo Photo) (err error)
+	WithContext(ctx context.Context) PhotoRepository
+	Insert(photo Photo) (id int, err error)
 	GetAllByUserId(id int) (photos []Photo, err error)
+	GetById(userId, photoId int) (photo Photo, err error)
+	GetAnyById(photoId int) (photo Photo, err error)
+	GetByFileName(name string) (photo Photo, err error)
 	UpdatePhotoById(photo Photo) (err error)
+	UpdateProcessedById(photo Photo) (err error)
+	ReplaceById(photo Photo) (err error)
 	DeletePhotoById(userId, photoId int) (err error)
+	GetTrashByUserId(userId int) (photos []Photo, err error)
+	RestorePhotoById(userId, photoId int) (err error)
+	GetAllDeletedBefore(before time.Time) (photos []Photo, err error)
+	GetAllByUserIdUnscoped(userId int) (photos []Photo, err error)
+	PurgeById(id int) (err error)
+	GetAllPhotoURLs() (urls []string, err error)
+	GetUsageByUserId(userId int) (bytes int64, photos int64, err error)
 }
 
 func NewPhotoRepository(conn *gorm.DB) PhotoRepository {
@@ -28,8 +66,15 @@ func NewPhotoRepository(conn *gorm.DB) PhotoRepository {
 	}
 }
 
-func (repository *PhotoDBConnectionRepository) Insert(photo Photo) (err error) {
+func (repository *PhotoDBConnectionRepository) WithContext(ctx context.Context) PhotoRepository {
+	return &PhotoDBConnectionRepository{
+		Conn: repository.Conn.WithContext(ctx),
+	}
+}
+
+func (repository *PhotoDBConnectionRepository) Insert(photo Photo) (id int, err error) {
 	err = repository.Conn.Create(&photo).Error
+	id = photo.ID
 
 	return
 }
@@ -40,14 +85,135 @@ func (repository *PhotoDBConnectionRepository) GetAllByUserId(id int) (photos []
 	return
 }
 
+func (repository *PhotoDBConnectionRepository) GetById(userId, photoId int) (photo Photo, err error) {
+	err = repository.Conn.Where("id = ? AND user_id = ?", photoId, userId).First(&photo).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = apperror.ErrPhotoNotFound.Wrap(err)
+	}
+
+	return
+}
+
+func (repository *PhotoDBConnectionRepository) GetAnyById(photoId int) (photo Photo, err error) {
+	err = repository.Conn.Where("id = ?", photoId).First(&photo).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = apperror.ErrPhotoNotFound.Wrap(err)
+	}
+
+	return
+}
+
+// GetByFileName finds the photo stored as ImageDir/name.<ext>, from either
+// its display or its original file. Variants share that name, so it serves
+// them too.
+func (repository *PhotoDBConnectionRepository) GetByFileName(name string) (photo Photo, err error) {
+	if name == "" || strings.ContainsAny(name, `%_\`) {
+		err = apperror.ErrPhotoNotFound
+		return
+	}
+
+	pattern := helpers.ImageDir + name + ".%"
+	err = repository.Conn.Where("photo_url LIKE ? OR original_url LIKE ?", pattern, pattern).First(&photo).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = apperror.ErrPhotoNotFound.Wrap(err)
+	}
+
+	return
+}
+
 func (repository *PhotoDBConnectionRepository) UpdatePhotoById(photo Photo) (err error) {
 	err = repository.Conn.Where("id = ? AND user_id = ?", photo.ID, photo.UserID).Updates(&photo).Error
 
 	return
 }
 
+// UpdateProcessedById also writes zero values, so a replaced file clears the
+// original and variants of the previous one.
+func (repository *PhotoDBConnectionRepository) UpdateProcessedById(photo Photo) (err error) {
+	err = repository.Conn.Model(&Photo{}).
+		Where("id = ? AND user_id = ?", photo.ID, photo.UserID).
+		Select("photo_url", "original_url", "variant_bytes", "status").
+		Updates(&photo).Error
+
+	return
+}
+
+// ReplaceById swaps in a new file and its metadata. Zero values are written
+// too, so nothing of the previous file is left behind on the row.
+func (repository *PhotoDBConnectionRepository) ReplaceById(photo Photo) (err error) {
+	err = repository.Conn.Model(&Photo{}).
+		Where("id = ? AND user_id = ?", photo.ID, photo.UserID).
+		Select("title", "caption", "tags", "album", "photo_url", "original_url", "variant_bytes", "status", "hash", "size", "width", "height").
+		Updates(&photo).Error
+
+	return
+}
+
 func (repository *PhotoDBConnectionRepository) DeletePhotoById(userId, photoId int) (err error) {
 	err = repository.Conn.Where("id = ? AND user_id = ?", photoId, userId).Delete(&Photo{}).Error
 
 	return
 }
+
+func (repository *PhotoDBConnectionRepository) GetTrashByUserId(userId int) (photos []Photo, err error) {
+	err = repository.Conn.Unscoped().Where("user_id = ? AND deleted_at IS NOT NULL", userId).Order("deleted_at DESC").Find(&photos).Error
+
+	return
+}
+
+func (repository *PhotoDBConnectionRepository) RestorePhotoById(userId, photoId int) (err error) {
+	result := repository.Conn.Unscoped().Model(&Photo{}).Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", photoId, userId).Update("deleted_at", nil)
+	err = result.Error
+	if err == nil && result.RowsAffected == 0 {
+		err = gorm.ErrRecordNotFound
+	}
+
+	return
+}
+
+func (repository *PhotoDBConnectionRepository) GetAllDeletedBefore(before time.Time) (photos []Photo, err error) {
+	err = repository.Conn.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", before).Find(&photos).Error
+
+	return
+}
+
+func (repository *PhotoDBConnectionRepository) GetAllByUserIdUnscoped(userId int) (photos []Photo, err error) {
+	err = repository.Conn.Unscoped().Where("user_id = ?", userId).Find(&photos).Error
+
+	return
+}
+
+func (repository *PhotoDBConnectionRepository) PurgeById(id int) (err error) {
+	err = repository.Conn.Unscoped().Where("id = ?", id).Delete(&Photo{}).Error
+
+	return
+}
+
+func (repository *PhotoDBConnectionRepository) GetAllPhotoURLs() (urls []string, err error) {
+	err = repository.Conn.Unscoped().Model(&Photo{}).Pluck("photo_url", &urls).Error
+	if err != nil {
+		return
+	}
+
+	var originals []string
+	err = repository.Conn.Unscoped().Model(&Photo{}).Where("original_url <> ''").Pluck("original_url", &originals).Error
+	urls = append(urls, originals...)
+
+	return
+}
+
+// GetUsageByUserId counts bytes of trashed photos too, since their files stay
+// on disk until purged, but only active photos towards the photo count.
+func (repository *PhotoDBConnectionRepository) GetUsageByUserId(userId int) (bytes int64, photos int64, err error) {
+	err = repository.Conn.Unscoped().Model(&Photo{}).
+		Where("user_id = ?", userId).
+		Select("COALESCE(SUM(size + variant_bytes), 0)").
+		Scan(&bytes).Error
+	if err != nil {
+		return
+	}
+
+	err = repository.Conn.Model(&Photo{}).Where("user_id = ?", userId).Count(&photos).Error
+
+	return
+}
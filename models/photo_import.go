@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ImportStatusPending    = "pending"
+	ImportStatusProcessing = "processing"
+	ImportStatusCompleted  = "completed"
+	ImportStatusFailed     = "failed"
+)
+
+type PhotoImport struct {
+	ID          string `gorm:"primaryKey;size:36"`
+	UserID      int    `gorm:"not null;index"`
+	Status      string `gorm:"not null;default:pending"`
+	ArchivePath string `gorm:"not null"`
+	Total       int
+	Processed   int
+	Imported    int
+	Skipped     int
+	Rejected    int
+	Results     string `gorm:"type:longtext"`
+	Error       string
+	CompletedAt *time.Time
+	CreatedAt   *time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	User        *User
+}
+
+type PhotoImportDBConnectionRepository struct {
+	Conn *gorm.DB
+}
+
+type PhotoImportRepository interface {
+	Insert(photoImport PhotoImport) (err error)
+	GetById(userId int, id string) (photoImport PhotoImport, err error)
+	Update(photoImport PhotoImport) (err error)
+}
+
+func NewPhotoImportRepository(conn *gorm.DB) PhotoImportRepository {
+	return &PhotoImportDBConnectionRepository{
+		Conn: conn,
+	}
+}
+
+func (repository *PhotoImportDBConnectionRepository) Insert(photoImport PhotoImport) (err error) {
+	err = repository.Conn.Create(&photoImport).Error
+
+	return
+}
+
+func (repository *PhotoImportDBConnectionRepository) GetById(userId int, id string) (photoImport PhotoImport, err error) {
+	err = repository.Conn.Where("id = ? AND user_id = ?", id, userId).First(&photoImport).Error
+
+	return
+}
+
+func (repository *PhotoImportDBConnectionRepository) Update(photoImport PhotoImport) (err error) {
+	err = repository.Conn.Where("id = ?", photoImport.ID).Updates(&photoImport).Error
+
+	return
+}
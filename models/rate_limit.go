@@ -0,0 +1,64 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RateLimitBucket struct {
+	Key        string    `gorm:"primaryKey;size:191"`
+	Tokens     float64   `gorm:"not null"`
+	RefilledAt time.Time `gorm:"not null;index"`
+}
+
+type RateLimitBucketDBConnectionRepository struct {
+	Conn *gorm.DB
+}
+
+type RateLimitBucketRepository interface {
+	WithContext(ctx context.Context) RateLimitBucketRepository
+	Take(initial RateLimitBucket, take func(bucket *RateLimitBucket)) (err error)
+	DeleteRefilledBefore(before time.Time) (err error)
+}
+
+func NewRateLimitBucketRepository(conn *gorm.DB) RateLimitBucketRepository {
+	return &RateLimitBucketDBConnectionRepository{
+		Conn: conn,
+	}
+}
+
+func (repository *RateLimitBucketDBConnectionRepository) WithContext(ctx context.Context) RateLimitBucketRepository {
+	return &RateLimitBucketDBConnectionRepository{
+		Conn: repository.Conn.WithContext(ctx),
+	}
+}
+
+func (repository *RateLimitBucketDBConnectionRepository) Take(initial RateLimitBucket, take func(bucket *RateLimitBucket)) (err error) {
+	err = repository.Conn.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&initial).Error
+		if err != nil {
+			return err
+		}
+
+		var bucket RateLimitBucket
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("`key` = ?", initial.Key).First(&bucket).Error
+		if err != nil {
+			return err
+		}
+
+		take(&bucket)
+
+		return tx.Save(&bucket).Error
+	})
+
+	return
+}
+
+func (repository *RateLimitBucketDBConnectionRepository) DeleteRefilledBefore(before time.Time) (err error) {
+	err = repository.Conn.Where("refilled_at < ?", before).Delete(&RateLimitBucket{}).Error
+
+	return
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Session struct {
+	ID         string     `gorm:"primaryKey;size:36"`
+	UserID     int        `gorm:"not null;index"`
+	UserAgent  string     `gorm:"not null"`
+	IP         string     `gorm:"not null"`
+	CreatedAt  *time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	LastSeenAt *time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	RevokedAt  *time.Time
+	User       *User
+}
+
+type SessionDBConnectionRepository struct {
+	Conn *gorm.DB
+}
+
+type SessionRepository interface {
+	WithContext(ctx context.Context) SessionRepository
+	Create(session Session) (err error)
+	GetById(id string) (session Session, err error)
+	GetAllByUserId(userId int) (sessions []Session, err error)
+	HasUserAgent(userId int, userAgent string) (found bool, err error)
+	Touch(id string) (err error)
+	RevokeById(userId int, id string) (err error)
+	RevokeAllByUserId(userId int) (err error)
+}
+
+func NewSessionRepository(conn *gorm.DB) SessionRepository {
+	return &SessionDBConnectionRepository{
+		Conn: conn,
+	}
+}
+
+func (repository *SessionDBConnectionRepository) WithContext(ctx context.Context) SessionRepository {
+	return &SessionDBConnectionRepository{
+		Conn: repository.Conn.WithContext(ctx),
+	}
+}
+
+func (repository *SessionDBConnectionRepository) Create(session Session) (err error) {
+	err = repository.Conn.Create(&session).Error
+
+	return
+}
+
+func (repository *SessionDBConnectionRepository) GetById(id string) (session Session, err error) {
+	err = repository.Conn.Where("id = ?", id).First(&session).Error
+
+	return
+}
+
+func (repository *SessionDBConnectionRepository) GetAllByUserId(userId int) (sessions []Session, err error) {
+	err = repository.Conn.Where("user_id = ? AND revoked_at IS NULL", userId).Order("last_seen_at DESC").Find(&sessions).Error
+
+	return
+}
+
+func (repository *SessionDBConnectionRepository) HasUserAgent(userId int, userAgent string) (found bool, err error) {
+	var count int64
+	err = repository.Conn.Model(&Session{}).Where("user_id = ? AND user_agent = ?", userId, userAgent).Count(&count).Error
+	found = count > 0
+
+	return
+}
+
+func (repository *SessionDBConnectionRepository) Touch(id string) (err error) {
+	err = repository.Conn.Model(&Session{}).Where("id = ?", id).Update("last_seen_at", time.Now()).Error
+
+	return
+}
+
+func (repository *SessionDBConnectionRepository) RevokeById(userId int, id string) (err error) {
+	result := repository.Conn.Model(&Session{}).Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userId).Update("revoked_at", time.Now())
+	err = result.Error
+	if err == nil && result.RowsAffected == 0 {
+		err = gorm.ErrRecordNotFound
+	}
+
+	return
+}
+
+func (repository *SessionDBConnectionRepository) RevokeAllByUserId(userId int) (err error) {
+	err = repository.Conn.Model(&Session{}).Where("user_id = ? AND revoked_at IS NULL", userId).Update("revoked_at", time.Now()).Error
+
+	return
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type StreamEvent struct {
+	ID        int        `gorm:"primaryKey"`
+	EventID   string     `gorm:"not null;size:36"`
+	Type      string     `gorm:"not null"`
+	UserID    int        `gorm:"not null;index"`
+	Data      string     `gorm:"type:text"`
+	CreatedAt *time.Time `gorm:"index"`
+}
+
+type StreamEventDBConnectionRepository struct {
+	Conn *gorm.DB
+}
+
+type StreamEventRepository interface {
+	Insert(event StreamEvent) (err error)
+	GetLastId() (id int, err error)
+	GetAllAfterId(id int, limit int) (events []StreamEvent, err error)
+	DeleteOlderThan(before time.Time) (err error)
+}
+
+func NewStreamEventRepository(conn *gorm.DB) StreamEventRepository {
+	return &StreamEventDBConnectionRepository{
+		Conn: conn,
+	}
+}
+
+func (repository *StreamEventDBConnectionRepository) Insert(event StreamEvent) (err error) {
+	err = repository.Conn.Create(&event).Error
+
+	return
+}
+
+func (repository *StreamEventDBConnectionRepository) GetLastId() (id int, err error) {
+	err = repository.Conn.Model(&StreamEvent{}).Select("COALESCE(MAX(id), 0)").Scan(&id).Error
+
+	return
+}
+
+func (repository *StreamEventDBConnectionRepository) GetAllAfterId(id int, limit int) (events []StreamEvent, err error) {
+	err = repository.Conn.Where("id > ?", id).Order("id").Limit(limit).Find(&events).Error
+
+	return
+}
+
+func (repository *StreamEventDBConnectionRepository) DeleteOlderThan(before time.Time) (err error) {
+	err = repository.Conn.Where("created_at < ?", before).Delete(&StreamEvent{}).Error
+
+	return
+}
@@ -1,21 +1,38 @@
 package models
 
 import (
+	"context"
 	"errors"
+	"rakamin/apperror"
 	"rakamin/helpers"
 	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"gorm.io/gorm"
 )
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
-	ID        int        `gorm:"primaryKey"`
-	Username  string     `gorm:"not null"`
-	Email     string     `gorm:"not null;unique"`
-	Password  string     `gorm:"not null"`
-	Photo     []Photo    `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
-	CreatedAt *time.Time `gorm:"default:CURRENT_TIMESTAMP"`
-	UpdatedAt *time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	ID           int    `gorm:"primaryKey"`
+	Username     string `gorm:"not null"`
+	Email        string `gorm:"not null;unique"`
+	Password     string `gorm:"not null"`
+	Role         string `gorm:"not null;default:user"`
+	Locale       string `gorm:"size:8"`
+	DisabledAt   *time.Time
+	Photo        []Photo        `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Session      []Session      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Notification []Notification `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	DataExport   []DataExport   `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	PhotoImport  []PhotoImport  `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Webhook      []Webhook      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	CreatedAt    *time.Time     `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt    *time.Time     `gorm:"default:CURRENT_TIMESTAMP"`
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
 }
 
 type UserDBConnectionRepository struct {
@@ -23,11 +40,18 @@ type UserDBConnectionRepository struct {
 }
 
 type UserRepository interface {
+	WithContext(ctx context.Context) UserRepository
 	GetByEmail(email string) (user User, err error)
 	Register(user User) (err error)
+	IsEmailTaken(email string) (taken bool, err error)
 	GetById(id int) (user User, err error)
 	UpdateById(id int, user User) (err error)
 	DeleteById(id int) (err error)
+	GetDeletedByEmail(email string) (user User, err error)
+	RestoreById(id int) (err error)
+	GetAllDeletedBefore(before time.Time) (users []User, err error)
+	PurgeById(id int) (err error)
+	SetDisabledById(id int, disabled bool) (err error)
 }
 
 func NewUserRepository(conn *gorm.DB) UserRepository {
@@ -36,15 +60,24 @@ func NewUserRepository(conn *gorm.DB) UserRepository {
 	}
 }
 
+func (repository *UserDBConnectionRepository) WithContext(ctx context.Context) UserRepository {
+	return &UserDBConnectionRepository{
+		Conn: repository.Conn.WithContext(ctx),
+	}
+}
+
 func (repository *UserDBConnectionRepository) Register(user User) (err error) {
 	user.Password, err = helpers.Hash(user.Password)
 	if err != nil {
 		return
 	}
 
-	_, err = repository.GetByEmail(user.Email)
-	if err == nil {
-		err = errors.New("duplicate email")
+	taken, err := repository.IsEmailTaken(user.Email)
+	if err != nil {
+		return
+	}
+	if taken {
+		err = apperror.ErrEmailTaken
 		return
 	}
 
@@ -53,6 +86,14 @@ func (repository *UserDBConnectionRepository) Register(user User) (err error) {
 	return
 }
 
+func (repository *UserDBConnectionRepository) IsEmailTaken(email string) (taken bool, err error) {
+	var count int64
+	err = repository.Conn.Unscoped().Model(&User{}).Where("email = ?", email).Count(&count).Error
+	taken = count > 0
+
+	return
+}
+
 func (repository *UserDBConnectionRepository) GetByEmail(email string) (user User, err error) {
 	err = repository.Conn.Where("email = ?", email).First(&user).Error
 
@@ -61,6 +102,9 @@ func (repository *UserDBConnectionRepository) GetByEmail(email string) (user Use
 
 func (repository *UserDBConnectionRepository) GetById(id int) (user User, err error) {
 	err = repository.Conn.Where("id = ?", id).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = apperror.ErrUserNotFound.Wrap(err)
+	}
 
 	return
 }
@@ -74,6 +118,11 @@ func (repository *UserDBConnectionRepository) UpdateById(id int, user User) (err
 	}
 	err = repository.Conn.Where("id = ?", id).Updates(&user).Error
 
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+		err = apperror.ErrEmailTaken.Wrap(err)
+	}
+
 	return
 }
 
@@ -82,3 +131,38 @@ func (repository *UserDBConnectionRepository) DeleteById(id int) (err error) {
 
 	return
 }
+
+func (repository *UserDBConnectionRepository) GetDeletedByEmail(email string) (user User, err error) {
+	err = repository.Conn.Unscoped().Where("email = ? AND deleted_at IS NOT NULL", email).First(&user).Error
+
+	return
+}
+
+func (repository *UserDBConnectionRepository) RestoreById(id int) (err error) {
+	err = repository.Conn.Unscoped().Model(&User{}).Where("id = ?", id).Update("deleted_at", nil).Error
+
+	return
+}
+
+func (repository *UserDBConnectionRepository) GetAllDeletedBefore(before time.Time) (users []User, err error) {
+	err = repository.Conn.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", before).Find(&users).Error
+
+	return
+}
+
+func (repository *UserDBConnectionRepository) PurgeById(id int) (err error) {
+	err = repository.Conn.Unscoped().Where("id = ?", id).Delete(&User{}).Error
+
+	return
+}
+
+func (repository *UserDBConnectionRepository) SetDisabledById(id int, disabled bool) (err error) {
+	var disabledAt *time.Time
+	if disabled {
+		now := time.Now()
+		disabledAt = &now
+	}
+	err = repository.Conn.Model(&User{}).Where("id = ?", id).Update("disabled_at", disabledAt).Error
+
+	return
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Webhook struct {
+	ID        int        `gorm:"primaryKey"`
+	UserID    int        `gorm:"not null;index"`
+	URL       string     `gorm:"not null"`
+	Secret    string     `gorm:"not null"`
+	Events    string     `gorm:"not null"`
+	Global    bool       `gorm:"not null;default:false"`
+	Active    bool       `gorm:"not null;default:true"`
+	CreatedAt *time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	User      *User
+}
+
+type WebhookDelivery struct {
+	ID           int    `gorm:"primaryKey"`
+	WebhookID    int    `gorm:"not null;index"`
+	EventID      string `gorm:"not null;size:36;index"`
+	EventType    string `gorm:"not null"`
+	Payload      string `gorm:"type:text"`
+	Attempt      int    `gorm:"not null"`
+	StatusCode   int
+	ResponseBody string `gorm:"type:text"`
+	Error        string `gorm:"type:text"`
+	DurationMs   int64
+	Success      bool       `gorm:"not null;default:false"`
+	CreatedAt    *time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	Webhook      *Webhook   `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+func (webhook Webhook) Subscribed(eventType string) bool {
+	for _, value := range strings.Split(webhook.Events, ",") {
+		if value == eventType || value == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+type WebhookDBConnectionRepository struct {
+	Conn *gorm.DB
+}
+
+type WebhookRepository interface {
+	Insert(webhook Webhook) (id int, err error)
+	GetById(id int) (webhook Webhook, err error)
+	GetAllByUserId(userId int) (webhooks []Webhook, err error)
+	GetAllActiveForUser(userId int) (webhooks []Webhook, err error)
+	DeleteById(userId, id int) (err error)
+	InsertDelivery(delivery WebhookDelivery) (err error)
+	GetDeliveryById(webhookId, id int) (delivery WebhookDelivery, err error)
+	GetAllDeliveriesByWebhookId(webhookId int, limit int) (deliveries []WebhookDelivery, err error)
+}
+
+func NewWebhookRepository(conn *gorm.DB) WebhookRepository {
+	return &WebhookDBConnectionRepository{
+		Conn: conn,
+	}
+}
+
+func (repository *WebhookDBConnectionRepository) Insert(webhook Webhook) (id int, err error) {
+	err = repository.Conn.Create(&webhook).Error
+	id = webhook.ID
+
+	return
+}
+
+func (repository *WebhookDBConnectionRepository) GetById(id int) (webhook Webhook, err error) {
+	err = repository.Conn.Where("id = ?", id).First(&webhook).Error
+
+	return
+}
+
+func (repository *WebhookDBConnectionRepository) GetAllByUserId(userId int) (webhooks []Webhook, err error) {
+	err = repository.Conn.Where("user_id = ?", userId).Find(&webhooks).Error
+
+	return
+}
+
+func (repository *WebhookDBConnectionRepository) GetAllActiveForUser(userId int) (webhooks []Webhook, err error) {
+	err = repository.Conn.Where("active = ? AND (user_id = ? OR global = ?)", true, userId, true).Find(&webhooks).Error
+
+	return
+}
+
+func (repository *WebhookDBConnectionRepository) DeleteById(userId, id int) (err error) {
+	result := repository.Conn.Where("id = ? AND user_id = ?", id, userId).Delete(&Webhook{})
+	err = result.Error
+	if err == nil && result.RowsAffected == 0 {
+		err = gorm.ErrRecordNotFound
+	}
+
+	return
+}
+
+func (repository *WebhookDBConnectionRepository) InsertDelivery(delivery WebhookDelivery) (err error) {
+	err = repository.Conn.Create(&delivery).Error
+
+	return
+}
+
+func (repository *WebhookDBConnectionRepository) GetDeliveryById(webhookId, id int) (delivery WebhookDelivery, err error) {
+	err = repository.Conn.Where("id = ? AND webhook_id = ?", id, webhookId).First(&delivery).Error
+
+	return
+}
+
+func (repository *WebhookDBConnectionRepository) GetAllDeliveriesByWebhookId(webhookId int, limit int) (deliveries []WebhookDelivery, err error) {
+	err = repository.Conn.Where("webhook_id = ?", webhookId).Order("id DESC").Limit(limit).Find(&deliveries).Error
+
+	return
+}
@@ -0,0 +1,76 @@
+package openapi
+
+import (
+	"net/http"
+	"rakamin/app"
+	"rakamin/apperror"
+)
+
+type Operation struct {
+	Method   string
+	Path     string
+	Tag      string
+	Summary  string
+	Auth     bool
+	Admin    bool
+	Body     interface{}
+	Form     interface{}
+	Query    interface{}
+	Response interface{}
+	Status   int
+	Produces string
+	// Errors lists what the handler can fail with beyond the errors every
+	// operation shares; see buildOperation.
+	Errors []*apperror.Error
+}
+
+var (
+	uploadErrors = []*apperror.Error{
+		apperror.ErrRequestTooLarge, apperror.ErrFileTooLarge, apperror.ErrInvalidFile, apperror.ErrInvalidFileType,
+		apperror.ErrImageTooLarge, apperror.ErrStorageQuota, apperror.ErrPhotoLimit, apperror.ErrFileUnreadable, apperror.ErrFileNotSaved,
+	}
+	webhookErrors = []*apperror.Error{apperror.ErrWebhookInvalidURL, apperror.ErrWebhookInvalidEvent}
+)
+
+var Operations = []Operation{
+	{Method: http.MethodPost, Path: "/api/v1/users/register", Tag: "users", Summary: "Register a new account", Body: app.RegisterRequest{}, Status: http.StatusCreated, Errors: []*apperror.Error{apperror.ErrEmailTaken}},
+	{Method: http.MethodPost, Path: "/api/v1/users/login", Tag: "users", Summary: "Log in and receive a session token", Body: app.LoginRequest{}, Response: app.LoginResponse{}, Errors: []*apperror.Error{apperror.ErrInvalidCredentials, apperror.ErrAccountDisabled}},
+	{Method: http.MethodGet, Path: "/api/v1/users/", Tag: "users", Summary: "Get the current user", Auth: true, Response: app.GetUserByIdResponse{}, Errors: []*apperror.Error{apperror.ErrUserNotFound}},
+	{Method: http.MethodPut, Path: "/api/v1/users/", Tag: "users", Summary: "Update the current user", Auth: true, Body: app.UpdateUserByIdRequest{}, Errors: []*apperror.Error{apperror.ErrUserNotFound, apperror.ErrEmailTaken}},
+	{Method: http.MethodDelete, Path: "/api/v1/users/", Tag: "users", Summary: "Schedule the current account for deletion", Auth: true, Errors: []*apperror.Error{apperror.ErrUserNotFound}},
+	{Method: http.MethodGet, Path: "/api/v1/users/sessions", Tag: "sessions", Summary: "List active sessions", Auth: true, Response: app.GetAllSessionResponse{}},
+	{Method: http.MethodDelete, Path: "/api/v1/users/sessions/:id", Tag: "sessions", Summary: "Revoke a session", Auth: true, Errors: []*apperror.Error{apperror.ErrSessionNotFound}},
+	{Method: http.MethodGet, Path: "/api/v1/users/notifications", Tag: "users", Summary: "List notifications", Auth: true, Response: app.GetAllNotificationResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/users/security-activity", Tag: "users", Summary: "List recent security activity", Auth: true, Response: app.GetAllAuditLogResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/users/export", Tag: "exports", Summary: "Start a personal data export", Auth: true, Response: app.DataExportResponse{}, Status: http.StatusAccepted},
+	{Method: http.MethodGet, Path: "/api/v1/users/export/:exportId", Tag: "exports", Summary: "Get a data export", Auth: true, Response: app.DataExportResponse{}, Errors: []*apperror.Error{apperror.ErrExportNotFound}},
+	{Method: http.MethodGet, Path: "/api/v1/exports/:token/download", Tag: "exports", Summary: "Download a data export archive", Produces: "application/zip", Errors: []*apperror.Error{apperror.ErrExportLinkExpired}},
+
+	{Method: http.MethodGet, Path: "/api/v1/photos/", Tag: "photos", Summary: "List photos", Auth: true, Response: app.GetAllPhotoByIdResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/photos/", Tag: "photos", Summary: "Upload a photo", Auth: true, Form: app.PhotoRequest{}, Status: http.StatusCreated, Errors: uploadErrors},
+	{Method: http.MethodPut, Path: "/api/v1/photos/:photoId", Tag: "photos", Summary: "Replace a photo", Auth: true, Form: app.UpdatePhotoByIdRequest{}, Errors: append([]*apperror.Error{apperror.ErrInvalidPhotoId, apperror.ErrPhotoNotFound}, uploadErrors...)},
+	{Method: http.MethodDelete, Path: "/api/v1/photos/:photoId", Tag: "photos", Summary: "Move a photo to the trash", Auth: true, Errors: []*apperror.Error{apperror.ErrPhotoNotFound}},
+	{Method: http.MethodGet, Path: "/api/v1/photos/trash", Tag: "photos", Summary: "List trashed photos", Auth: true, Response: app.GetAllTrashPhotoResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/photos/:photoId/restore", Tag: "photos", Summary: "Restore a trashed photo", Auth: true, Errors: []*apperror.Error{apperror.ErrPhotoNotInTrash}},
+	{Method: http.MethodGet, Path: "/api/v1/photos/:photoId/transform-url", Tag: "photos", Summary: "Get a signed URL for a resized or converted photo", Auth: true, Query: app.TransformRequest{}, Response: app.TransformURLResponse{}, Errors: []*apperror.Error{apperror.ErrInvalidPhotoId, apperror.ErrInvalidTransform, apperror.ErrPhotoNotFound}},
+	{Method: http.MethodGet, Path: "/img/:photoId", Tag: "photos", Summary: "Serve a resized or converted photo from a signed URL", Query: app.TransformRequest{}, Produces: "image/*", Errors: []*apperror.Error{apperror.ErrInvalidPhotoId, apperror.ErrInvalidTransform, apperror.ErrInvalidSignature, apperror.ErrSignatureExpired, apperror.ErrPhotoNotFound, apperror.ErrCannotTransform}},
+	{Method: http.MethodPost, Path: "/api/v1/photos/import", Tag: "imports", Summary: "Import photos from a ZIP archive", Auth: true, Form: app.PhotoImportRequest{}, Response: app.PhotoImportResponse{}, Status: http.StatusAccepted, Errors: []*apperror.Error{apperror.ErrRequestTooLarge, apperror.ErrInvalidArchive, apperror.ErrFileNotSaved}},
+	{Method: http.MethodGet, Path: "/api/v1/photos/import/:importId", Tag: "imports", Summary: "Get import progress", Auth: true, Response: app.PhotoImportResponse{}, Errors: []*apperror.Error{apperror.ErrImportNotFound}},
+
+	{Method: http.MethodGet, Path: "/api/v1/events", Tag: "events", Summary: "Stream events over Server-Sent Events", Auth: true, Produces: "text/event-stream"},
+	{Method: http.MethodGet, Path: "/api/v1/events/ws", Tag: "events", Summary: "Stream events over WebSocket", Auth: true, Status: http.StatusSwitchingProtocols},
+
+	{Method: http.MethodGet, Path: "/api/v1/webhooks/", Tag: "webhooks", Summary: "List webhooks", Auth: true, Response: app.GetAllWebhookResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/webhooks/", Tag: "webhooks", Summary: "Register a webhook", Auth: true, Body: app.CreateWebhookRequest{}, Response: app.CreateWebhookResponse{}, Status: http.StatusCreated, Errors: webhookErrors},
+	{Method: http.MethodDelete, Path: "/api/v1/webhooks/:webhookId", Tag: "webhooks", Summary: "Delete a webhook", Auth: true, Errors: []*apperror.Error{apperror.ErrWebhookNotFound}},
+	{Method: http.MethodPost, Path: "/api/v1/webhooks/:webhookId/ping", Tag: "webhooks", Summary: "Send a ping event", Auth: true, Status: http.StatusAccepted, Errors: []*apperror.Error{apperror.ErrWebhookNotFound}},
+	{Method: http.MethodGet, Path: "/api/v1/webhooks/:webhookId/deliveries", Tag: "webhooks", Summary: "List webhook deliveries", Auth: true, Response: app.GetAllWebhookDeliveryResponse{}, Errors: []*apperror.Error{apperror.ErrWebhookNotFound}},
+	{Method: http.MethodPost, Path: "/api/v1/webhooks/:webhookId/deliveries/:deliveryId/redeliver", Tag: "webhooks", Summary: "Redeliver a webhook event", Auth: true, Status: http.StatusAccepted, Errors: []*apperror.Error{apperror.ErrWebhookNotFound, apperror.ErrDeliveryNotFound}},
+
+	{Method: http.MethodGet, Path: "/api/v1/admin/audit-logs", Tag: "admin", Summary: "Query the audit log", Auth: true, Admin: true, Query: app.GetAllAuditLogRequest{}, Response: app.GetAllAuditLogResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/admin/jobs", Tag: "admin", Summary: "List background jobs", Auth: true, Admin: true, Query: app.GetAllJobRequest{}, Response: app.GetAllJobResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/admin/jobs/:jobId", Tag: "admin", Summary: "Get a background job", Auth: true, Admin: true, Response: app.Jobs{}, Errors: []*apperror.Error{apperror.ErrJobNotFound}},
+	{Method: http.MethodPost, Path: "/api/v1/admin/jobs/:jobId/retry", Tag: "admin", Summary: "Retry a background job", Auth: true, Admin: true, Errors: []*apperror.Error{apperror.ErrJobNotRetryable}},
+	{Method: http.MethodPost, Path: "/api/v1/admin/webhooks", Tag: "admin", Summary: "Register a global webhook", Auth: true, Admin: true, Body: app.CreateWebhookRequest{}, Response: app.CreateWebhookResponse{}, Status: http.StatusCreated, Errors: webhookErrors},
+	{Method: http.MethodGet, Path: "/api/v1/admin/config", Tag: "admin", Summary: "Show the active config version and reload history", Auth: true, Admin: true, Response: app.ConfigStatusResponse{}},
+}
@@ -0,0 +1,138 @@
+package openapi
+
+import (
+	"mime/multipart"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	fileHeaderType = reflect.TypeOf(multipart.FileHeader{})
+)
+
+type schemaRegistry struct {
+	schemas map[string]interface{}
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{schemas: map[string]interface{}{}}
+}
+
+func (r *schemaRegistry) schemaOf(t reflect.Type, tag string) map[string]interface{} {
+	if t == nil {
+		return map[string]interface{}{}
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == fileHeaderType:
+		return map[string]interface{}{"type": "string", "format": "binary"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": r.schemaOf(t.Elem(), tag)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": r.schemaOf(t.Elem(), tag)}
+	case reflect.Interface:
+		return map[string]interface{}{}
+	case reflect.Struct:
+		if tag != "json" || t.Name() == "" {
+			return r.objectOf(t, tag)
+		}
+		if _, ok := r.schemas[t.Name()]; !ok {
+			r.schemas[t.Name()] = map[string]interface{}{}
+			r.schemas[t.Name()] = r.objectOf(t, tag)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	}
+
+	return map[string]interface{}{}
+}
+
+func (r *schemaRegistry) objectOf(t reflect.Type, tag string) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+
+	for _, field := range fieldsOf(t, tag) {
+		properties[field.name] = r.schemaOf(field.field.Type, tag)
+		if field.required {
+			required = append(required, field.name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return schema
+}
+
+type fieldInfo struct {
+	name     string
+	required bool
+	field    reflect.StructField
+}
+
+func fieldsOf(t reflect.Type, tag string) []fieldInfo {
+	var fields []fieldInfo
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+		if value, ok := field.Tag.Lookup(tag); ok {
+			value = strings.Split(value, ",")[0]
+			if value == "-" {
+				continue
+			}
+			if value != "" {
+				name = value
+			}
+		} else if tag != "json" {
+			continue
+		}
+
+		fields = append(fields, fieldInfo{
+			name:     name,
+			required: strings.Contains(field.Tag.Get("binding"), "required"),
+			field:    field,
+		})
+	}
+
+	return fields
+}
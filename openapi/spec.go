@@ -0,0 +1,265 @@
+package openapi
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"rakamin/apperror"
+	"rakamin/helpers"
+	"rakamin/metrics"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	SpecPath       = "/api/openapi.json"
+	DocsPath       = "/api/docs"
+	DocsAssetsPath = "/api/docs/assets"
+)
+
+var (
+	//go:embed docs.html
+	DocsPage []byte
+
+	// Swagger UI 4.15.5 is vendored so the docs don't depend on a CDN.
+	//go:embed swagger-ui
+	docsAssets embed.FS
+)
+
+var (
+	pathParam = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+	ignored   = []string{SpecPath, DocsPath, DocsAssetsPath + "/*filepath", metrics.Path, "/public/images/*filepath", "/healthz", "/readyz"}
+)
+
+func DocsAssets() http.FileSystem {
+	assets, err := fs.Sub(docsAssets, "swagger-ui")
+	if err != nil {
+		panic(err)
+	}
+
+	return http.FS(assets)
+}
+
+func Build() map[string]interface{} {
+	registry := newSchemaRegistry()
+	paths := map[string]interface{}{}
+
+	for _, operation := range Operations {
+		path := pathParam.ReplaceAllString(operation.Path, "{$1}")
+		item, ok := paths[path].(map[string]interface{})
+		if !ok {
+			item = map[string]interface{}{}
+			paths[path] = item
+		}
+		item[strings.ToLower(operation.Method)] = buildOperation(registry, operation)
+	}
+	// Each error response narrows meta.code to what that route can return;
+	// this is the full set for clients that want one type.
+	registry.schemas["ErrorCode"] = map[string]interface{}{"type": "string", "enum": apperror.Codes()}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "Rakamin Photo API",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": registry.schemas,
+			"securitySchemes": map[string]interface{}{
+				"token": map[string]interface{}{
+					"type":        "apiKey",
+					"in":          "header",
+					"name":        "Authorization",
+					"description": "Token returned by POST /api/v1/users/login, sent without a scheme prefix",
+				},
+			},
+		},
+	}
+}
+
+func buildOperation(registry *schemaRegistry, operation Operation) map[string]interface{} {
+	parameters := []interface{}{}
+	for _, match := range pathParam.FindAllStringSubmatch(operation.Path, -1) {
+		parameters = append(parameters, map[string]interface{}{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "string"},
+		})
+	}
+	if operation.Query != nil {
+		for _, field := range fieldsOf(reflect.TypeOf(operation.Query), "form") {
+			parameters = append(parameters, map[string]interface{}{
+				"name":     field.name,
+				"in":       "query",
+				"required": field.required,
+				"schema":   registry.schemaOf(field.field.Type, "form"),
+			})
+		}
+	}
+
+	status := operation.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	// Every documented route sits behind a rate limit, and anything can fail
+	// internally; the rest depends on what the route binds and guards.
+	errs := []*apperror.Error{apperror.ErrInternal, apperror.ErrRateLimited}
+	if operation.Body != nil || operation.Form != nil || operation.Query != nil || len(parameters) > 0 {
+		errs = append(errs, apperror.ErrValidation, apperror.ErrMalformedRequest)
+	}
+	if operation.Auth {
+		errs = append(errs, apperror.ErrTokenMissing, apperror.ErrTokenInvalid, apperror.ErrSessionRevoked)
+	}
+	if operation.Admin {
+		errs = append(errs, apperror.ErrForbidden)
+	}
+	errs = append(errs, operation.Errors...)
+
+	byStatus := map[int][]*apperror.Error{}
+	for _, err := range errs {
+		byStatus[err.Status] = append(byStatus[err.Status], err)
+	}
+
+	responses := map[string]interface{}{
+		fmt.Sprint(status): successResponse(registry, operation),
+	}
+	for errStatus, statusErrs := range byStatus {
+		responses[fmt.Sprint(errStatus)] = errorResponse(registry, statusErrs)
+	}
+
+	result := map[string]interface{}{
+		"tags":        []string{operation.Tag},
+		"summary":     operation.Summary,
+		"operationId": operationId(operation),
+		"parameters":  parameters,
+		"responses":   responses,
+	}
+	if operation.Auth {
+		result["security"] = []interface{}{map[string]interface{}{"token": []string{}}}
+	}
+
+	switch {
+	case operation.Body != nil:
+		result["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": registry.schemaOf(reflect.TypeOf(operation.Body), "json")},
+			},
+		}
+	case operation.Form != nil:
+		result["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"multipart/form-data": map[string]interface{}{"schema": registry.schemaOf(reflect.TypeOf(operation.Form), "form")},
+			},
+		}
+	}
+
+	return result
+}
+
+func successResponse(registry *schemaRegistry, operation Operation) map[string]interface{} {
+	if operation.Produces != "" {
+		return map[string]interface{}{
+			"description": "Success",
+			"content": map[string]interface{}{
+				operation.Produces: map[string]interface{}{"schema": map[string]interface{}{"type": "string", "format": "binary"}},
+			},
+		}
+	}
+	if operation.Status == http.StatusSwitchingProtocols {
+		return map[string]interface{}{"description": "Switching to the WebSocket protocol"}
+	}
+
+	envelope := registry.objectOf(reflect.TypeOf(helpers.BaseResponse{}), "json")
+	if operation.Response != nil {
+		envelope["properties"].(map[string]interface{})["data"] = registry.schemaOf(reflect.TypeOf(operation.Response), "json")
+	}
+
+	return map[string]interface{}{
+		"description": "Success",
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": envelope},
+		},
+	}
+}
+
+func errorResponse(registry *schemaRegistry, errs []*apperror.Error) map[string]interface{} {
+	codes := make([]string, 0, len(errs))
+	lines := make([]string, 0, len(errs))
+	for _, err := range errs {
+		codes = append(codes, err.Code)
+		lines = append(lines, fmt.Sprintf("- `%s`: %s", err.Code, err.Message))
+	}
+
+	envelope := registry.objectOf(reflect.TypeOf(helpers.BaseResponse{}), "json")
+	meta := envelope["properties"].(map[string]interface{})["meta"].(map[string]interface{})
+	meta["properties"].(map[string]interface{})["code"] = map[string]interface{}{"type": "string", "enum": codes}
+
+	return map[string]interface{}{
+		"description": strings.Join(lines, "\n"),
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": envelope},
+		},
+	}
+}
+
+func operationId(operation Operation) string {
+	parts := []string{strings.ToLower(operation.Method)}
+	for _, part := range strings.Split(operation.Path, "/") {
+		part = strings.TrimLeft(part, ":*")
+		if part == "" || part == "api" || part == "v1" {
+			continue
+		}
+		parts = append(parts, strings.ToUpper(part[:1])+part[1:])
+	}
+
+	return strings.Join(parts, "")
+}
+
+func Verify(routes gin.RoutesInfo) error {
+	documented := map[string]bool{}
+	for _, operation := range Operations {
+		documented[operation.Method+" "+operation.Path] = true
+	}
+
+	skip := map[string]bool{}
+	for _, path := range ignored {
+		skip[path] = true
+	}
+
+	var undocumented []string
+	for _, route := range routes {
+		key := route.Method + " " + route.Path
+		if skip[route.Path] {
+			continue
+		}
+		if documented[key] {
+			delete(documented, key)
+			continue
+		}
+		undocumented = append(undocumented, key)
+	}
+
+	var stale []string
+	for key := range documented {
+		stale = append(stale, key)
+	}
+
+	if len(undocumented) == 0 && len(stale) == 0 {
+		return nil
+	}
+
+	sort.Strings(undocumented)
+	sort.Strings(stale)
+
+	return fmt.Errorf("openapi spec drifted from registered routes: undocumented %v, not registered %v", undocumented, stale)
+}
@@ -0,0 +1,82 @@
+package quota
+
+import (
+	"context"
+	"rakamin/apperror"
+	"rakamin/models"
+)
+
+// Limits of zero mean unlimited.
+type Limits struct {
+	MaxBytes    int64
+	MaxPhotos   int64
+	MaxFileSize int64
+}
+
+type Usage struct {
+	Plan      string
+	UsedBytes int64
+	Photos    int64
+	Limits    Limits
+}
+
+type Checker struct {
+	userRepo  models.UserRepository
+	photoRepo models.PhotoRepository
+	plans     map[string]Limits
+}
+
+func NewChecker(userRepo models.UserRepository, photoRepo models.PhotoRepository, plans map[string]Limits) *Checker {
+	return &Checker{
+		userRepo:  userRepo,
+		photoRepo: photoRepo,
+		plans:     plans,
+	}
+}
+
+// plan picks the limits for the user's role, falling back to the plain user
+// plan for roles without their own entry.
+func (c *Checker) plan(role string) (string, Limits) {
+	if limits, ok := c.plans[role]; ok {
+		return role, limits
+	}
+
+	return models.RoleUser, c.plans[models.RoleUser]
+}
+
+func (c *Checker) Usage(ctx context.Context, userId int) (usage Usage, err error) {
+	user, err := c.userRepo.WithContext(ctx).GetById(userId)
+	if err != nil {
+		return
+	}
+
+	usage.Plan, usage.Limits = c.plan(user.Role)
+	usage.UsedBytes, usage.Photos, err = c.photoRepo.WithContext(ctx).GetUsageByUserId(userId)
+
+	return
+}
+
+// Check reports whether a file of size bytes fits the user's plan. When the
+// file replaces an existing photo pass its bytes as replaced and newPhoto false.
+func (c *Checker) Check(ctx context.Context, userId int, size, replaced int64, newPhoto bool) error {
+	usage, err := c.Usage(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	return usage.Check(size, replaced, newPhoto)
+}
+
+func (u Usage) Check(size, replaced int64, newPhoto bool) error {
+	if u.Limits.MaxFileSize > 0 && size > u.Limits.MaxFileSize {
+		return apperror.ErrFileTooLarge
+	}
+	if newPhoto && u.Limits.MaxPhotos > 0 && u.Photos+1 > u.Limits.MaxPhotos {
+		return apperror.ErrPhotoLimit
+	}
+	if u.Limits.MaxBytes > 0 && u.UsedBytes-replaced+size > u.Limits.MaxBytes {
+		return apperror.ErrStorageQuota
+	}
+
+	return nil
+}
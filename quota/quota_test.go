@@ -0,0 +1,63 @@
+package quota
+
+import (
+	"errors"
+	"rakamin/apperror"
+	"rakamin/models"
+	"testing"
+)
+
+func TestUsageCheck(t *testing.T) {
+	limits := Limits{MaxBytes: 1000, MaxPhotos: 3, MaxFileSize: 400}
+
+	tests := []struct {
+		name     string
+		usage    Usage
+		size     int64
+		replaced int64
+		newPhoto bool
+		want     error
+	}{
+		{"fits", Usage{UsedBytes: 100, Photos: 1, Limits: limits}, 400, 0, true, nil},
+		{"file too large", Usage{Limits: limits}, 401, 0, true, apperror.ErrFileTooLarge},
+		{"photo limit", Usage{Photos: 3, Limits: limits}, 10, 0, true, apperror.ErrPhotoLimit},
+		{"replacing at photo limit", Usage{UsedBytes: 300, Photos: 3, Limits: limits}, 10, 100, false, nil},
+		{"exactly full", Usage{UsedBytes: 600, Photos: 1, Limits: limits}, 400, 0, true, nil},
+		{"storage quota", Usage{UsedBytes: 601, Photos: 1, Limits: limits}, 400, 0, true, apperror.ErrStorageQuota},
+		{"replaced bytes are freed", Usage{UsedBytes: 900, Photos: 1, Limits: limits}, 300, 200, false, nil},
+		{"replacement still too big", Usage{UsedBytes: 900, Photos: 1, Limits: limits}, 300, 100, false, apperror.ErrStorageQuota},
+		{"unlimited", Usage{UsedBytes: 1 << 40, Photos: 1 << 20}, 1 << 30, 0, true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.usage.Check(tt.size, tt.replaced, tt.newPhoto); !errors.Is(err, tt.want) {
+				t.Errorf("Check = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckerPlan(t *testing.T) {
+	checker := NewChecker(nil, nil, map[string]Limits{
+		models.RoleUser:  {MaxBytes: 100},
+		models.RoleAdmin: {},
+	})
+
+	tests := []struct {
+		role   string
+		plan   string
+		limits Limits
+	}{
+		{models.RoleUser, models.RoleUser, Limits{MaxBytes: 100}},
+		{models.RoleAdmin, models.RoleAdmin, Limits{}},
+		{"auditor", models.RoleUser, Limits{MaxBytes: 100}},
+	}
+
+	for _, tt := range tests {
+		plan, limits := checker.plan(tt.role)
+		if plan != tt.plan || limits != tt.limits {
+			t.Errorf("plan(%q) = %q %+v, want %q %+v", tt.role, plan, limits, tt.plan, tt.limits)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	ByUser  = "user"
+	ByToken = "token"
+	ByIP    = "ip"
+)
+
+type Policy struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+	By       string
+}
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+type Store interface {
+	Take(ctx context.Context, key string, policy Policy, now time.Time) (Result, error)
+	Start(ctx context.Context, wg *sync.WaitGroup)
+}
+
+func (p Policy) Enabled() bool {
+	return p.Requests > 0 && p.Period > 0
+}
+
+func (p Policy) capacity() float64 {
+	if p.Burst > 0 {
+		return float64(p.Burst)
+	}
+
+	return float64(p.Requests)
+}
+
+// rate is the refill speed in tokens per second.
+func (p Policy) rate() float64 {
+	return float64(p.Requests) / p.Period.Seconds()
+}
+
+func (p Policy) refill(tokens float64, refilledAt, now time.Time) float64 {
+	elapsed := now.Sub(refilledAt).Seconds()
+	if elapsed < 0 {
+		elapsed = 0
+	}
+
+	return math.Min(p.capacity(), tokens+elapsed*p.rate())
+}
+
+func take(policy Policy, tokens float64, refilledAt, now time.Time) (float64, Result) {
+	tokens = policy.refill(tokens, refilledAt, now)
+	result := Result{Limit: int(policy.capacity())}
+
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - tokens) / policy.rate())
+	}
+
+	result.Remaining = int(math.Floor(tokens))
+	result.Reset = seconds((policy.capacity() - tokens) / policy.rate())
+
+	return tokens, result
+}
+
+func seconds(value float64) time.Duration {
+	return time.Duration(math.Ceil(value)) * time.Second
+}
@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTake(t *testing.T) {
+	start := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	// 10 requests a minute refills a token every 6 seconds.
+	policy := Policy{Requests: 10, Period: time.Minute}
+	burst := Policy{Requests: 10, Period: time.Minute, Burst: 3}
+
+	tests := []struct {
+		name       string
+		policy     Policy
+		tokens     float64
+		elapsed    time.Duration
+		allowed    bool
+		remaining  int
+		left       float64
+		retryAfter time.Duration
+		reset      time.Duration
+	}{
+		{"full bucket", policy, 10, 0, true, 9, 9, 0, 6 * time.Second},
+		{"last token", policy, 1, 0, true, 0, 0, 0, time.Minute},
+		{"empty bucket", policy, 0, 0, false, 0, 0, 6 * time.Second, time.Minute},
+		{"partly refilled", policy, 0, 3 * time.Second, false, 0, 0.5, 3 * time.Second, 57 * time.Second},
+		{"refilled one token", policy, 0, 6 * time.Second, true, 0, 0, 0, time.Minute},
+		{"refill stops at capacity", policy, 0, time.Hour, true, 9, 9, 0, 6 * time.Second},
+		{"burst caps capacity", burst, 0, time.Hour, true, 2, 2, 0, 6 * time.Second},
+		{"clock going back refills nothing", policy, 0, -time.Minute, false, 0, 0, 6 * time.Second, time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			left, result := take(tt.policy, tt.tokens, start, start.Add(tt.elapsed))
+
+			if result.Allowed != tt.allowed || result.Remaining != tt.remaining {
+				t.Errorf("allowed %v remaining %d, want %v %d", result.Allowed, result.Remaining, tt.allowed, tt.remaining)
+			}
+			if left < tt.left-1e-9 || left > tt.left+1e-9 {
+				t.Errorf("%f tokens left, want %f", left, tt.left)
+			}
+			if result.RetryAfter != tt.retryAfter || result.Reset != tt.reset {
+				t.Errorf("retry after %s reset %s, want %s %s", result.RetryAfter, result.Reset, tt.retryAfter, tt.reset)
+			}
+			if result.Limit != int(tt.policy.capacity()) {
+				t.Errorf("limit %d, want %d", result.Limit, int(tt.policy.capacity()))
+			}
+		})
+	}
+}
+
+func TestPolicyEnabled(t *testing.T) {
+	tests := []struct {
+		policy Policy
+		want   bool
+	}{
+		{Policy{Requests: 10, Period: time.Minute}, true},
+		{Policy{Requests: 0, Period: time.Minute}, false},
+		{Policy{Requests: 10}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.policy.Enabled(); got != tt.want {
+			t.Errorf("%+v Enabled = %v, want %v", tt.policy, got, tt.want)
+		}
+	}
+}
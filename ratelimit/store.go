@@ -0,0 +1,125 @@
+package ratelimit
+
+import (
+	"context"
+	"rakamin/logger"
+	"rakamin/models"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens     float64
+	refilledAt time.Time
+	policy     Policy
+}
+
+type MemoryStore struct {
+	mu            sync.Mutex
+	buckets       map[string]*bucket
+	PruneInterval time.Duration
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:       map[string]*bucket{},
+		PruneInterval: time.Minute,
+	}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, policy Policy, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: policy.capacity(), refilledAt: now}
+		s.buckets[key] = b
+	}
+
+	var result Result
+	b.tokens, result = take(policy, b.tokens, b.refilledAt, now)
+	b.refilledAt = now
+	b.policy = policy
+
+	return result, nil
+}
+
+func (s *MemoryStore) Start(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(s.PruneInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.prune(now)
+			}
+		}
+	}()
+}
+
+// prune drops buckets that have refilled completely, since a fresh bucket
+// would behave the same.
+func (s *MemoryStore) prune(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, b := range s.buckets {
+		if b.policy.refill(b.tokens, b.refilledAt, now) >= b.policy.capacity() {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+type DatabaseStore struct {
+	bucketRepo models.RateLimitBucketRepository
+	Retention  time.Duration
+}
+
+func NewDatabaseStore(bucketRepo models.RateLimitBucketRepository) *DatabaseStore {
+	return &DatabaseStore{
+		bucketRepo: bucketRepo,
+		Retention:  24 * time.Hour,
+	}
+}
+
+func (s *DatabaseStore) Take(ctx context.Context, key string, policy Policy, now time.Time) (result Result, err error) {
+	err = s.bucketRepo.WithContext(ctx).Take(models.RateLimitBucket{
+		Key:        key,
+		Tokens:     policy.capacity(),
+		RefilledAt: now,
+	}, func(bucket *models.RateLimitBucket) {
+		bucket.Tokens, result = take(policy, bucket.Tokens, bucket.RefilledAt, now)
+		bucket.RefilledAt = now
+	})
+
+	return
+}
+
+func (s *DatabaseStore) Start(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := s.bucketRepo.DeleteRefilledBefore(time.Now().Add(-s.Retention))
+				if err != nil {
+					logger.Log.Error().Err(err).Msg("pruning rate limit buckets")
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	start := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	strict := Policy{Requests: 2, Period: time.Minute}
+	loose := Policy{Requests: 5, Period: time.Minute}
+
+	tests := []struct {
+		name    string
+		key     string
+		policy  Policy
+		at      time.Duration
+		allowed bool
+	}{
+		{"first", "a", strict, 0, true},
+		{"second", "a", strict, 0, true},
+		{"over the limit", "a", strict, time.Second, false},
+		{"other key has its own bucket", "b", strict, time.Second, true},
+		{"refilled", "a", strict, 30 * time.Second, true},
+		{"other policy has its own limit", "c", loose, 30 * time.Second, true},
+		{"empty again", "a", strict, 31 * time.Second, false},
+	}
+
+	store := NewMemoryStore()
+	for _, tt := range tests {
+		result, err := store.Take(context.Background(), tt.key, tt.policy, start.Add(tt.at))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Allowed != tt.allowed {
+			t.Errorf("%s: allowed %v, want %v", tt.name, result.Allowed, tt.allowed)
+		}
+	}
+}
+
+func TestMemoryStorePrune(t *testing.T) {
+	start := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	policy := Policy{Requests: 10, Period: time.Minute}
+
+	tests := []struct {
+		name  string
+		after time.Duration
+		kept  bool
+	}{
+		{"still refilling", 5 * time.Second, true},
+		{"refilled completely", 6 * time.Second, false},
+		{"long idle", time.Hour, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			_, err := store.Take(context.Background(), "a", policy, start)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			store.prune(start.Add(tt.after))
+
+			if _, kept := store.buckets["a"]; kept != tt.kept {
+				t.Errorf("kept %v, want %v", kept, tt.kept)
+			}
+		})
+	}
+}
@@ -1,32 +1,105 @@
 package router
 
 import (
+	"net/http"
 	"rakamin/controllers"
+	"rakamin/logger"
+	"rakamin/metrics"
 	"rakamin/middlewares"
+	"rakamin/openapi"
+	"rakamin/tracing"
 
 	"github.com/gin-gonic/gin"
 )
 
 type ControllerList struct {
-	AuthMiddleware  *middlewares.AuthorizationMiddleware
-	UserController  controllers.UserController
-	PhotoController controllers.PhotoController
+	AuthMiddleware         *middlewares.AuthorizationMiddleware
+	AdminMiddleware        *middlewares.AdminMiddleware
+	LocaleMiddleware       *middlewares.LocaleMiddleware
+	RateLimitMiddleware    *middlewares.RateLimitMiddleware
+	MetricsHandler         http.Handler
+	MaxUploadSize          int64
+	MaxArchiveSize         int64
+	UserController         controllers.UserController
+	SessionController      controllers.SessionController
+	NotificationController controllers.NotificationController
+	PhotoController        controllers.PhotoController
+	AuditLogController     controllers.AuditLogController
+	DataExportController   controllers.DataExportController
+	PhotoImportController  controllers.PhotoImportController
+	JobController          controllers.JobController
+	WebhookController      controllers.WebhookController
+	EventController        controllers.EventController
+	DocsController         controllers.DocsController
+	ImageController        controllers.ImageController
+	TransformController    controllers.TransformController
+	HealthController       controllers.HealthController
+	ConfigController       controllers.ConfigController
 }
 
 func (cl *ControllerList) RouteRegister(g *gin.Engine) {
-	g.Static("/public/images", "./public/images")
+	// probes are registered ahead of the middleware chain to keep them out of logs, metrics and traces
+	g.GET("/healthz", cl.HealthController.Live)
+	g.GET("/readyz", cl.HealthController.Ready)
+	g.Use(tracing.Middleware())
+	g.Use(middlewares.RequestID())
+	g.Use(logger.Middleware())
+	g.Use(metrics.Middleware())
+	g.Use(cl.LocaleMiddleware.Locale())
+	if cl.MetricsHandler != nil {
+		g.GET(metrics.Path, gin.WrapH(cl.MetricsHandler))
+	}
+	g.GET("/public/images/*filepath", cl.ImageController.Serve)
+	g.HEAD("/public/images/*filepath", cl.ImageController.Serve)
+	g.GET(openapi.SpecPath, cl.DocsController.Spec)
+	g.GET(openapi.DocsPath, cl.DocsController.UI)
+	g.StaticFS(openapi.DocsAssetsPath, openapi.DocsAssets())
 	apiV1 := g.Group("api/v1")
+	limit := cl.RateLimitMiddleware.Limit
 
 	user := apiV1.Group("/users")
-	user.POST("/register", cl.UserController.Register)
-	user.POST("/login", cl.UserController.Login)
-	user.GET("/", cl.UserController.GetUserById, cl.AuthMiddleware.Authorization())
-	user.PUT("/", cl.UserController.UpdateUserById, cl.AuthMiddleware.Authorization())
-	user.DELETE("/", cl.UserController.DeleteUserById, cl.AuthMiddleware.Authorization())
+	user.POST("/register", limit("auth"), cl.UserController.Register)
+	user.POST("/login", limit("auth"), cl.UserController.Login)
+	user.GET("/", cl.AuthMiddleware.Authorization(), limit("api"), cl.UserController.GetUserById)
+	user.PUT("/", cl.AuthMiddleware.Authorization(), limit("api"), cl.UserController.UpdateUserById)
+	user.DELETE("/", cl.AuthMiddleware.Authorization(), limit("api"), cl.UserController.DeleteUserById)
+	user.GET("/sessions", cl.AuthMiddleware.Authorization(), limit("api"), cl.SessionController.GetSessions)
+	user.DELETE("/sessions/:id", cl.AuthMiddleware.Authorization(), limit("api"), cl.SessionController.RevokeSessionById)
+	user.GET("/notifications", cl.AuthMiddleware.Authorization(), limit("api"), cl.NotificationController.GetNotifications)
+	user.GET("/security-activity", cl.AuthMiddleware.Authorization(), limit("api"), cl.AuditLogController.GetSecurityActivity)
+	user.POST("/export", cl.AuthMiddleware.Authorization(), limit("api"), cl.DataExportController.RequestExport)
+	user.GET("/export/:exportId", cl.AuthMiddleware.Authorization(), limit("api"), cl.DataExportController.GetExportById)
 
-	photo := apiV1.Group("/photos", cl.AuthMiddleware.Authorization())
+	apiV1.GET("/exports/:token/download", limit("download"), cl.DataExportController.Download)
+	g.GET("/img/:photoId", limit("download"), cl.TransformController.Serve)
+
+	photo := apiV1.Group("/photos", cl.AuthMiddleware.Authorization(), limit("api"))
 	photo.GET("/", cl.PhotoController.GetPhotos)
-	photo.POST("/", cl.PhotoController.Upload)
-	photo.PUT("/:photoId", cl.PhotoController.UpdatePhotoById)
+	photo.GET("/trash", cl.PhotoController.GetTrash)
+	photo.POST("/import", limit("upload"), middlewares.BodyLimit(cl.MaxArchiveSize), cl.PhotoImportController.Import)
+	photo.GET("/import/:importId", cl.PhotoImportController.GetImportById)
+	photo.POST("/:photoId/restore", cl.PhotoController.RestorePhotoById)
+	photo.GET("/:photoId/transform-url", cl.TransformController.SignURL)
+	photo.POST("/", limit("upload"), middlewares.BodyLimit(cl.MaxUploadSize), cl.PhotoController.Upload)
+	photo.PUT("/:photoId", limit("upload"), middlewares.BodyLimit(cl.MaxUploadSize), cl.PhotoController.UpdatePhotoById)
 	photo.DELETE("/:photoId", cl.PhotoController.DeletePhotoById)
+
+	apiV1.GET("/events", cl.AuthMiddleware.AllowQueryToken(), cl.AuthMiddleware.Authorization(), limit("api"), cl.EventController.Stream)
+	apiV1.GET("/events/ws", cl.AuthMiddleware.AllowQueryToken(), cl.AuthMiddleware.Authorization(), limit("api"), cl.EventController.WebSocket)
+
+	webhook := apiV1.Group("/webhooks", cl.AuthMiddleware.Authorization(), limit("api"))
+	webhook.GET("/", cl.WebhookController.GetWebhooks)
+	webhook.POST("/", cl.WebhookController.Create)
+	webhook.DELETE("/:webhookId", cl.WebhookController.DeleteWebhookById)
+	webhook.POST("/:webhookId/ping", cl.WebhookController.Ping)
+	webhook.GET("/:webhookId/deliveries", cl.WebhookController.GetDeliveries)
+	webhook.POST("/:webhookId/deliveries/:deliveryId/redeliver", cl.WebhookController.Redeliver)
+
+	admin := apiV1.Group("/admin", cl.AuthMiddleware.Authorization(), limit("api"), cl.AdminMiddleware.Admin())
+	admin.GET("/audit-logs", cl.AuditLogController.GetAuditLogs)
+	admin.GET("/jobs", cl.JobController.GetJobs)
+	admin.GET("/jobs/:jobId", cl.JobController.GetJobById)
+	admin.POST("/jobs/:jobId/retry", cl.JobController.RetryJobById)
+	admin.POST("/webhooks", cl.WebhookController.CreateGlobal)
+	admin.GET("/config", cl.ConfigController.GetConfigStatus)
 }
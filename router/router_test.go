@@ -0,0 +1,92 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"rakamin/openapi"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	g := gin.New()
+	cl := ControllerList{}
+	cl.RouteRegister(g)
+
+	return g
+}
+
+func TestRoutesMatchOpenAPISpec(t *testing.T) {
+	err := openapi.Verify(newEngine().Routes())
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyReportsUndocumentedRoute(t *testing.T) {
+	g := newEngine()
+	g.GET("/api/v1/undocumented", func(g *gin.Context) {})
+
+	err := openapi.Verify(g.Routes())
+	if err == nil {
+		t.Fatal("expected drift for an undocumented route")
+	}
+}
+
+func TestDocsAssetsAreEmbedded(t *testing.T) {
+	g := newEngine()
+
+	for _, path := range []string{openapi.DocsAssetsPath + "/swagger-ui.css", openapi.DocsAssetsPath + "/swagger-ui-bundle.js"} {
+		w := httptest.NewRecorder()
+		g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK || w.Body.Len() == 0 {
+			t.Errorf("GET %s: status %d, %d bytes", path, w.Code, w.Body.Len())
+		}
+	}
+}
+
+func TestSpecDocumentsErrorCodes(t *testing.T) {
+	paths := openapi.Build()["paths"].(map[string]interface{})
+
+	tests := []struct {
+		path   string
+		method string
+		status string
+		code   string
+	}{
+		{"/api/v1/photos/", "post", "413", "FILE_TOO_LARGE"},
+		{"/api/v1/photos/", "post", "415", "INVALID_FILE_TYPE"},
+		{"/api/v1/photos/", "post", "403", "QUOTA_EXCEEDED"},
+		{"/api/v1/photos/", "post", "429", "RATE_LIMITED"},
+		{"/api/v1/users/register", "post", "409", "EMAIL_TAKEN"},
+		{"/api/v1/admin/jobs/{jobId}/retry", "post", "409", "JOB_NOT_RETRYABLE"},
+		{"/api/v1/admin/jobs/{jobId}/retry", "post", "403", "FORBIDDEN"},
+		{"/img/{photoId}", "get", "410", "SIGNATURE_EXPIRED"},
+		{"/img/{photoId}", "get", "422", "CANNOT_TRANSFORM"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path+" "+tt.status, func(t *testing.T) {
+			operation, ok := paths[tt.path].(map[string]interface{})[tt.method].(map[string]interface{})
+			if !ok {
+				t.Fatalf("operation not in spec")
+			}
+			response, ok := operation["responses"].(map[string]interface{})[tt.status].(map[string]interface{})
+			if !ok {
+				t.Fatalf("no %s response", tt.status)
+			}
+
+			schema := response["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"].(map[string]interface{})
+			meta := schema["properties"].(map[string]interface{})["meta"].(map[string]interface{})
+			codes := meta["properties"].(map[string]interface{})["code"].(map[string]interface{})["enum"].([]string)
+			for _, code := range codes {
+				if code == tt.code {
+					return
+				}
+			}
+			t.Errorf("code enum %v is missing %s", codes, tt.code)
+		})
+	}
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"rakamin/apperror"
+	"rakamin/controllers"
+	"rakamin/database"
+	"rakamin/events"
+	"rakamin/helpers"
+	"rakamin/i18n"
+	"rakamin/logger"
+	"rakamin/metrics"
+	"rakamin/middlewares"
+	"rakamin/models"
+	"rakamin/openapi"
+	"rakamin/quota"
+	"rakamin/ratelimit"
+	"rakamin/router"
+	"rakamin/tracing"
+	"rakamin/transform"
+	"rakamin/validation"
+	"rakamin/workers"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+func runServe(args []string) error {
+	flags := newFlagSet("serve")
+	migrate := flags.Bool("migrate", true, "apply database migrations before serving")
+	flags.Parse(args)
+
+	configApp, err := helpers.LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	err = logger.Setup(logger.Config{
+		Level:  configApp.Logging.Level,
+		Format: configApp.Logging.Format,
+	})
+	if err != nil {
+		logger.Log.Fatal().Err(err).Msg("configuring logger")
+	}
+
+	mysqlDB, err := openDB(configApp)
+	if err != nil {
+		logger.Log.Fatal().Err(err).Msg("connecting to database")
+	}
+	if *migrate {
+		err = database.Migrate(mysqlDB)
+		if err != nil {
+			logger.Log.Fatal().Err(err).Msg("migrating database")
+		}
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    configApp.Tracing.Exporter,
+		Endpoint:    configApp.Tracing.Endpoint,
+		Insecure:    configApp.Tracing.Insecure,
+		ServiceName: configApp.Tracing.ServiceName,
+		SampleRatio: configApp.Tracing.SampleRatio,
+	})
+	if err != nil {
+		logger.Log.Fatal().Err(err).Msg("configuring tracing")
+	}
+
+	err = tracing.InstrumentGORM(mysqlDB)
+	if err != nil {
+		logger.Log.Fatal().Err(err).Msg("instrumenting database tracing")
+	}
+	i18n.SetDefaultLocale(configApp.I18n.DefaultLocale)
+	helpers.SetAllowedImageTypes(configApp.Uploads.AllowedTypes)
+	configWatcher := helpers.NewConfigWatcher(configPath, configApp)
+	configWatcher.OnReload(func(conf helpers.Config) {
+		logger.SetLevel(conf.Logging.Level)
+		helpers.SetAllowedImageTypes(conf.Uploads.AllowedTypes)
+	})
+	gracePeriod := time.Duration(configApp.Trash.GraceDays) * 24 * time.Hour
+	sessionRepo := models.NewSessionRepository(mysqlDB)
+	authMiddleware := middlewares.NewAuthorizationMiddleware(configApp.JWT.Secret, configApp.JWT.Expiry, sessionRepo)
+
+	userRepo := models.NewUserRepository(mysqlDB)
+	adminMiddleware := middlewares.NewAdminMiddleware(userRepo, authMiddleware)
+	localeMiddleware := middlewares.NewLocaleMiddleware(userRepo, authMiddleware)
+	var rateLimitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if configApp.RateLimit.Store == "database" {
+		rateLimitStore = ratelimit.NewDatabaseStore(models.NewRateLimitBucketRepository(mysqlDB))
+	}
+	rateLimitMiddleware := middlewares.NewRateLimitMiddleware(rateLimitStore, rateLimitPolicies(configApp), authMiddleware)
+	configWatcher.OnReload(func(conf helpers.Config) {
+		rateLimitMiddleware.SetPolicies(rateLimitPolicies(conf))
+	})
+	notificationRepo := models.NewNotificationRepository(mysqlDB)
+	auditLogRepo := models.NewAuditLogRepository(mysqlDB)
+	jobRepo := models.NewJobRepository(mysqlDB)
+	jobQueue := workers.NewJobQueue(jobRepo, configApp.Jobs.Concurrency, configApp.Jobs.MaxAttempts, configApp.Jobs.Backoff, configApp.Jobs.PollInterval)
+	jobController := controllers.NewJobController(jobRepo, auditLogRepo, authMiddleware)
+	webhookRepo := models.NewWebhookRepository(mysqlDB)
+	webhookDispatcher := workers.NewWebhookDispatcher(webhookRepo, jobQueue, configApp.Webhooks.Timeout, configApp.Webhooks.AllowPrivateTargets)
+	webhookController := controllers.NewWebhookController(webhookRepo, auditLogRepo, webhookDispatcher, authMiddleware)
+	var broker events.Broker = events.NewLocalBroker()
+	if configApp.Events.Broker == "database" {
+		broker = events.NewDatabaseBroker(models.NewStreamEventRepository(mysqlDB), configApp.Events.PollInterval)
+	}
+	eventHub := events.NewHub(broker, configApp.Events.BacklogSize, configApp.Events.ReplayWindow)
+	eventController := controllers.NewEventController(eventHub, sessionRepo, authMiddleware)
+	publisher := events.MultiPublisher{webhookDispatcher, eventHub}
+	photoRepo := models.NewPhotoRepository(mysqlDB)
+	quotaChecker := quota.NewChecker(userRepo, photoRepo, quotaPlans(configApp))
+	imageStore := helpers.NewImageStore(helpers.ImageLimits{
+		MaxWidth:  configApp.Uploads.MaxWidth,
+		MaxHeight: configApp.Uploads.MaxHeight,
+		MaxPixels: int64(configApp.Uploads.MaxMegapixels * 1e6),
+	}, configApp.Uploads.MaxConcurrent)
+	userController := controllers.NewUserController(userRepo, sessionRepo, notificationRepo, auditLogRepo, quotaChecker, publisher, authMiddleware, gracePeriod)
+	sessionController := controllers.NewSessionController(sessionRepo, auditLogRepo, authMiddleware)
+	notificationController := controllers.NewNotificationController(notificationRepo, authMiddleware)
+	auditLogController := controllers.NewAuditLogController(auditLogRepo, authMiddleware)
+	if converter := strings.Fields(configApp.Images.HEICConverter); len(converter) > 0 {
+		if _, err := exec.LookPath(converter[0]); err != nil {
+			logger.Log.Warn().Err(err).Msg("heic converter not found, heic photos will fail processing")
+		}
+	}
+	if converter := strings.Fields(configApp.Images.WebPConverter); len(converter) > 0 {
+		if _, err := exec.LookPath(converter[0]); err != nil {
+			logger.Log.Warn().Err(err).Msg("webp converter not found, webp variants and transforms will fail")
+		}
+	}
+	photoProcessor := workers.NewPhotoProcessor(photoRepo, jobQueue, publisher, configApp.Images.HEICConverter, configApp.Images.WebPConverter, configApp.Images.WebPQuality)
+	photoController := controllers.NewPhotoController(photoRepo, auditLogRepo, photoProcessor, quotaChecker, imageStore, publisher, authMiddleware, gracePeriod)
+	transformCache, err := transform.NewCache(configApp.Images.CacheDir, configApp.Images.CacheSizeMB<<20)
+	if err != nil {
+		logger.Log.Fatal().Err(err).Msg("opening transform cache")
+	}
+	transformController := controllers.NewTransformController(photoRepo, transform.NewSigner(configApp.Images.SigningKey), transformCache, configApp.Images.WebPConverter, configApp.Images.LinkTTL, configApp.Images.MaxDimension, configApp.Images.TransformConcurrency, authMiddleware)
+	exportRepo := models.NewDataExportRepository(mysqlDB)
+	exportWorker := workers.NewDataExportWorker(exportRepo, userRepo, photoRepo, sessionRepo, notificationRepo, auditLogRepo, jobQueue, configApp.Export.Dir, configApp.Export.LinkTTL)
+	exportController := controllers.NewDataExportController(exportRepo, auditLogRepo, exportWorker, authMiddleware)
+	validator := validation.NewValidator(userRepo)
+	binding.Validator = validator
+	err = validator.Setup()
+	if err != nil {
+		logger.Log.Fatal().Err(err).Msg("configuring validator")
+	}
+
+	importRepo := models.NewPhotoImportRepository(mysqlDB)
+	importWorker := workers.NewPhotoImportWorker(importRepo, userRepo, photoRepo, notificationRepo, photoProcessor, quotaChecker, imageStore, jobQueue, publisher, validator, int64(configApp.Import.MaxFileSizeMB)<<20, configApp.Import.MaxEntries)
+	importController := controllers.NewPhotoImportController(importRepo, importWorker, authMiddleware, configApp.Import.Dir)
+
+	healthController := controllers.NewHealthController(map[string]controllers.HealthCheck{
+		"database": func(ctx context.Context) error {
+			return database.Ping(ctx, mysqlDB)
+		},
+		"migrations": func(ctx context.Context) error {
+			return database.CheckMigrations(mysqlDB.WithContext(ctx))
+		},
+		"storage": func(ctx context.Context) error {
+			return helpers.CheckWritable(helpers.ImageDir, configApp.Export.Dir, configApp.Import.Dir, configApp.Images.CacheDir)
+		},
+	})
+
+	// Every background goroutine joins workerGroup, so the database pool is
+	// only closed once they have all returned.
+	var workerGroup sync.WaitGroup
+	workerCtx, cancelWorkers := context.WithCancel(context.Background())
+	workers.NewAuditRetentionWorker(auditLogRepo, configApp.Audit.RetentionDays).Start(workerCtx, &workerGroup)
+	workers.NewTrashPurgeWorker(userRepo, photoRepo, gracePeriod).Start(workerCtx, &workerGroup)
+	exportWorker.Start(workerCtx, &workerGroup)
+	jobQueue.Start(workerCtx)
+	eventHub.Start(workerCtx, &workerGroup)
+	configWatcher.Start(workerCtx, &workerGroup)
+	rateLimitStore.Start(workerCtx, &workerGroup)
+
+	r := gin.New()
+	r.MaxMultipartMemory = configApp.Uploads.MultipartMemoryMB << 20
+	r.Use(gin.CustomRecoveryWithWriter(nil, func(g *gin.Context, recovered interface{}) {
+		logger.FromContext(g.Request.Context()).Error().Interface("panic", recovered).Msg("recovered from panic")
+		helpers.AbortWithError(g, apperror.ErrInternal)
+	}))
+	router := router.ControllerList{
+		AuthMiddleware:         authMiddleware,
+		AdminMiddleware:        adminMiddleware,
+		LocaleMiddleware:       localeMiddleware,
+		RateLimitMiddleware:    rateLimitMiddleware,
+		MaxUploadSize:          configApp.Uploads.MaxRequestSizeMB << 20,
+		MaxArchiveSize:         configApp.Import.MaxArchiveSizeMB << 20,
+		UserController:         *userController,
+		SessionController:      *sessionController,
+		NotificationController: *notificationController,
+		PhotoController:        *photoController,
+		AuditLogController:     *auditLogController,
+		DataExportController:   *exportController,
+		PhotoImportController:  *importController,
+		JobController:          *jobController,
+		WebhookController:      *webhookController,
+		EventController:        *eventController,
+		DocsController:         *controllers.NewDocsController(),
+		ImageController:        *controllers.NewImageController(photoRepo),
+		TransformController:    *transformController,
+		HealthController:       *healthController,
+		ConfigController:       *controllers.NewConfigController(configWatcher),
+	}
+
+	if configApp.Metrics.Enabled {
+		err = metrics.InstrumentGORM(mysqlDB)
+		if err != nil {
+			logger.Log.Fatal().Err(err).Msg("instrumenting database metrics")
+		}
+
+		sqlDB, err := mysqlDB.DB()
+		if err != nil {
+			logger.Log.Fatal().Err(err).Msg("opening database pool")
+		}
+		metrics.RegisterDBStats(sqlDB)
+		metrics.RegisterJobQueueDepth(jobRepo.CountByStatus)
+
+		metricsHandler := metrics.Handler(configApp.Metrics.Token)
+		if configApp.Metrics.Address == "" {
+			router.MetricsHandler = metricsHandler
+		} else {
+			go func() {
+				mux := http.NewServeMux()
+				mux.Handle(metrics.Path, metricsHandler)
+				err := http.ListenAndServe(configApp.Metrics.Address, mux)
+				logger.Log.Fatal().Err(err).Msg("serving metrics")
+			}()
+		}
+	}
+
+	router.RouteRegister(r)
+
+	// Drift fails router tests in CI, at runtime it only costs docs accuracy.
+	err = openapi.Verify(r.Routes())
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("openapi spec drifted from routes")
+	}
+
+	server := &http.Server{
+		Addr:              configApp.Server.Address,
+		Handler:           r,
+		ReadTimeout:       configApp.Server.ReadTimeout,
+		ReadHeaderTimeout: configApp.Server.ReadHeaderTimeout,
+		WriteTimeout:      configApp.Server.WriteTimeout,
+		IdleTimeout:       configApp.Server.IdleTimeout,
+	}
+	server.RegisterOnShutdown(eventHub.Close)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		logger.Log.Info().Str("address", configApp.Server.Address).Msg("listening")
+		err := server.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Log.Fatal().Err(err).Msg("serving http")
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+	logger.Log.Info().Msg("shutting down")
+	healthController.SetShuttingDown()
+
+	// Keep serving while /readyz fails, so load balancers stop routing here
+	// before the listener closes.
+	if configApp.Server.DrainDelay > 0 {
+		logger.Log.Info().Dur("delay", configApp.Server.DrainDelay).Msg("waiting for load balancers to drain")
+		time.Sleep(configApp.Server.DrainDelay)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), configApp.Server.ShutdownTimeout)
+	defer cancel()
+
+	err = server.Shutdown(shutdownCtx)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("draining http requests")
+	}
+
+	cancelWorkers()
+	drained := make(chan struct{})
+	go func() {
+		jobQueue.Wait()
+		workerGroup.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-shutdownCtx.Done():
+		logger.Log.Warn().Msg("timed out waiting for background workers")
+	}
+
+	err = shutdownTracing(shutdownCtx)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("flushing traces")
+	}
+
+	sqlDB, err := mysqlDB.DB()
+	if err == nil {
+		err = sqlDB.Close()
+	}
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("closing database pool")
+	}
+
+	logger.Log.Info().Msg("shutdown complete")
+
+	return nil
+}
+
+func rateLimitPolicies(configApp helpers.Config) map[string]ratelimit.Policy {
+	policies := map[string]ratelimit.Policy{}
+	for name, policy := range configApp.RateLimit.Policies {
+		policies[name] = ratelimit.Policy{
+			Requests: policy.Requests,
+			Period:   policy.Period,
+			Burst:    policy.Burst,
+			By:       policy.By,
+		}
+	}
+
+	return policies
+}
+
+func quotaPlans(configApp helpers.Config) map[string]quota.Limits {
+	plans := map[string]quota.Limits{}
+	for name, plan := range configApp.Quotas {
+		plans[name] = quota.Limits{
+			MaxBytes:    plan.MaxStorageMB << 20,
+			MaxPhotos:   plan.MaxPhotos,
+			MaxFileSize: plan.MaxFileSizeMB << 20,
+		}
+	}
+
+	return plans
+}
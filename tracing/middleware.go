@@ -0,0 +1,55 @@
+package tracing
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const ContextKey = "traceId"
+
+func Middleware() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(g.Request.Context(), propagation.HeaderCarrier(g.Request.Header))
+
+		route := g.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		ctx, span := otel.Tracer(instrumentationName).Start(ctx, g.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethod(g.Request.Method),
+				semconv.HTTPRoute(route),
+				semconv.HTTPTarget(g.Request.URL.Path),
+				semconv.HTTPUserAgent(g.Request.UserAgent()),
+				semconv.NetSockPeerAddr(g.ClientIP()),
+			),
+		)
+		defer span.End()
+
+		g.Request = g.Request.WithContext(ctx)
+		if traceId := TraceID(ctx); traceId != "" {
+			g.Set(ContextKey, traceId)
+			g.Header("X-Trace-ID", traceId)
+		}
+		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(g.Writer.Header()))
+
+		g.Next()
+
+		status := g.Writer.Status()
+		span.SetAttributes(semconv.HTTPStatusCode(status))
+		if status >= 500 {
+			span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", status))
+		}
+		for _, err := range g.Errors {
+			span.RecordError(err.Err)
+		}
+	}
+}
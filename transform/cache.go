@@ -0,0 +1,155 @@
+package transform
+
+import (
+	"container/list"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache keeps transform results on disk and drops the least recently used
+// ones once the total size goes over maxBytes. Recency survives restarts
+// through the files' modification times.
+type Cache struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	size    int64
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	name string
+	size int64
+}
+
+func NewCache(dir string, maxBytes int64) (*Cache, error) {
+	err := os.MkdirAll(dir, 0750)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []os.FileInfo
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		if strings.HasPrefix(file.Name(), ".") {
+			os.Remove(filepath.Join(dir, file.Name()))
+			continue
+		}
+
+		info, err := file.Info()
+		if err == nil {
+			infos = append(infos, info)
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ModTime().Before(infos[j].ModTime())
+	})
+
+	cache := &Cache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  map[string]*list.Element{},
+	}
+	for _, info := range infos {
+		cache.entries[info.Name()] = cache.order.PushFront(&cacheEntry{name: info.Name(), size: info.Size()})
+		cache.size += info.Size()
+	}
+	cache.evict()
+
+	return cache, nil
+}
+
+// Get opens the entry under the lock, so an eviction racing with the caller
+// only unlinks the name and the returned file can still be read in full.
+func (c *Cache) Get(name string) (file *os.File, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[name]
+	if !ok {
+		return
+	}
+
+	path := filepath.Join(c.dir, name)
+	file, err := os.Open(path)
+	if err != nil {
+		c.remove(element)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+
+	now := time.Now()
+	os.Chtimes(path, now, now)
+
+	return
+}
+
+// Put stores data under name and returns it opened, see Get.
+func (c *Cache) Put(name string, data []byte) (file *os.File, err error) {
+	tmp, err := os.CreateTemp(c.dir, ".transform-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	closeErr := tmp.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := filepath.Join(c.dir, name)
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+
+	if element, ok := c.entries[name]; ok {
+		c.remove(element)
+	}
+	c.entries[name] = c.order.PushFront(&cacheEntry{name: name, size: int64(len(data))})
+	c.size += int64(len(data))
+	c.evict()
+
+	file, err = os.Open(path)
+
+	return
+}
+
+// evict keeps the newest entry even when it alone is over the cap, it is
+// about to be served.
+func (c *Cache) evict() {
+	for c.size > c.maxBytes && c.order.Len() > 1 {
+		element := c.order.Back()
+		os.Remove(filepath.Join(c.dir, element.Value.(*cacheEntry).name))
+		c.remove(element)
+	}
+}
+
+func (c *Cache) remove(element *list.Element) {
+	entry := element.Value.(*cacheEntry)
+
+	c.order.Remove(element)
+	delete(c.entries, entry.name)
+	c.size -= entry.size
+}
@@ -0,0 +1,129 @@
+package transform
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readAndClose(t *testing.T, file *os.File) string {
+	t.Helper()
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache, err := NewCache(t.TempDir(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"a", "b"} {
+		file, err := cache.Put(name, []byte(strings.Repeat(name, 4)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		file.Close()
+	}
+
+	// Reading a makes b the oldest entry.
+	file, ok := cache.Get("a")
+	if !ok {
+		t.Fatal("a is missing")
+	}
+	file.Close()
+
+	file, err = cache.Put("c", []byte("cccc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"a", true},
+		{"b", false},
+		{"c", true},
+	}
+	for _, tt := range tests {
+		file, ok := cache.Get(tt.name)
+		if ok != tt.want {
+			t.Errorf("Get(%q) ok = %v, want %v", tt.name, ok, tt.want)
+		}
+		if ok {
+			file.Close()
+		}
+		if _, err := os.Stat(filepath.Join(cache.dir, tt.name)); (err == nil) != tt.want {
+			t.Errorf("%s on disk = %v, want %v", tt.name, err == nil, tt.want)
+		}
+	}
+}
+
+func TestCacheHandleSurvivesEviction(t *testing.T) {
+	cache, err := NewCache(t.TempDir(), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := cache.Put("a", []byte("aaaa"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	served, ok := cache.Get("a")
+	if !ok {
+		t.Fatal("a is missing")
+	}
+	file.Close()
+
+	file, err = cache.Put("b", []byte("bbbb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	if _, ok := cache.Get("a"); ok {
+		t.Fatal("a wasn't evicted")
+	}
+
+	if got := readAndClose(t, served); got != "aaaa" {
+		t.Errorf("served %q after eviction, want aaaa", got)
+	}
+}
+
+func TestCacheKeepsEntriesAcrossRestarts(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewCache(dir, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := cache.Put("a", []byte("aaaa"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	os.WriteFile(filepath.Join(dir, ".transform-leftover"), []byte("x"), 0600)
+
+	cache, err = NewCache(dir, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, ok := cache.Get("a")
+	if !ok {
+		t.Fatal("a was lost on restart")
+	}
+	if got := readAndClose(t, file); got != "aaaa" {
+		t.Errorf("read %q, want aaaa", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".transform-leftover")); !os.IsNotExist(err) {
+		t.Error("leftover temp file wasn't removed")
+	}
+}
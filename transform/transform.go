@@ -0,0 +1,262 @@
+package transform
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"rakamin/helpers"
+	"strconv"
+	"time"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	FitContain = "contain"
+	FitCover   = "cover"
+	FitFill    = "fill"
+
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+
+	DefaultQuality = 80
+)
+
+// Params of zero take the defaults: keep the aspect ratio for a missing
+// dimension, fit contain, DefaultQuality and the source's format.
+type Params struct {
+	Width   int
+	Height  int
+	Fit     string
+	Quality int
+	Format  string
+}
+
+func (p Params) Validate(maxDimension int) bool {
+	if p.Width < 0 || p.Height < 0 || p.Width > maxDimension || p.Height > maxDimension {
+		return false
+	}
+	if p.Quality < 0 || p.Quality > 100 {
+		return false
+	}
+	switch p.Fit {
+	case "", FitContain, FitCover, FitFill:
+	default:
+		return false
+	}
+	switch p.Format {
+	case "", FormatJPEG, FormatPNG, FormatWebP:
+	default:
+		return false
+	}
+
+	return true
+}
+
+func (p Params) canonical(photoId int) string {
+	return fmt.Sprintf("%d:%d:%d:%s:%d:%s", photoId, p.Width, p.Height, p.Fit, p.Quality, p.Format)
+}
+
+// Query encodes the non-default params the way the endpoint reads them.
+func (p Params) Query() url.Values {
+	query := url.Values{}
+	if p.Width > 0 {
+		query.Set("w", strconv.Itoa(p.Width))
+	}
+	if p.Height > 0 {
+		query.Set("h", strconv.Itoa(p.Height))
+	}
+	if p.Fit != "" {
+		query.Set("fit", p.Fit)
+	}
+	if p.Quality > 0 {
+		query.Set("q", strconv.Itoa(p.Quality))
+	}
+	if p.Format != "" {
+		query.Set("fmt", p.Format)
+	}
+
+	return query
+}
+
+// Key names the cached result. The source goes in so a replaced photo never
+// hits the result of its previous file.
+func (p Params) Key(photoId int, source, format string) string {
+	sum := sha256.Sum256([]byte(source + "|" + p.canonical(photoId) + "|" + format))
+
+	return hex.EncodeToString(sum[:]) + "." + format
+}
+
+type Signer struct {
+	key []byte
+}
+
+func NewSigner(key string) *Signer {
+	return &Signer{key: []byte(key)}
+}
+
+// Sign covers the params and the expiry, so neither can be changed without
+// invalidating the signature.
+func (s *Signer) Sign(photoId int, p Params, expires int64) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(p.canonical(photoId) + ":" + strconv.FormatInt(expires, 10)))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify only checks the signature, the caller compares expires with the
+// clock so an expired link can be told apart from a forged one.
+func (s *Signer) Verify(photoId int, p Params, expires int64, signature string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(p.canonical(photoId) + ":" + strconv.FormatInt(expires, 10)))
+
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+func (s *Signer) URL(path string, photoId int, p Params, expiresAt time.Time) string {
+	query := p.Query()
+	query.Set("exp", strconv.FormatInt(expiresAt.Unix(), 10))
+	query.Set("s", s.Sign(photoId, p, expiresAt.Unix()))
+
+	return path + "?" + query.Encode()
+}
+
+// SourceFormat maps a stored type to the output format used when none is
+// requested. Types without a Go decoder map to "".
+func SourceFormat(filetype string) string {
+	switch filetype {
+	case helpers.ImageTypeJPEG:
+		return FormatJPEG
+	case helpers.ImageTypePNG, helpers.ImageTypeGIF:
+		return FormatPNG
+	case helpers.ImageTypeWebP:
+		return FormatWebP
+	}
+
+	return ""
+}
+
+// Apply resizes src without ever enlarging it, except for fit fill which
+// stretches to exactly the requested size.
+func Apply(src image.Image, p Params) image.Image {
+	bounds := src.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	w, h := p.Width, p.Height
+	if (w == 0 && h == 0) || sw == 0 || sh == 0 {
+		return src
+	}
+	if w == 0 {
+		w = scale(sw, h, sh)
+	}
+	if h == 0 {
+		h = scale(sh, w, sw)
+	}
+
+	crop := bounds
+	switch p.Fit {
+	case FitFill:
+	case FitCover:
+		if sw*h > sh*w {
+			cw := scale(sh, w, h)
+			crop = image.Rect(0, 0, cw, sh).Add(image.Pt(bounds.Min.X+(sw-cw)/2, bounds.Min.Y))
+		} else {
+			ch := scale(sw, h, w)
+			crop = image.Rect(0, 0, sw, ch).Add(image.Pt(bounds.Min.X, bounds.Min.Y+(sh-ch)/2))
+		}
+		if w > crop.Dx() {
+			w, h = crop.Dx(), crop.Dy()
+		}
+	default:
+		if sw*h > sh*w {
+			h = scale(sh, w, sw)
+		} else {
+			w = scale(sw, h, sh)
+		}
+		if w > sw {
+			w, h = sw, sh
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Src, nil)
+
+	return dst
+}
+
+// scale returns n*num/den rounded, at least 1.
+func scale(n, num, den int) int {
+	v := (int64(n)*int64(num) + int64(den)/2) / int64(den)
+	if v < 1 {
+		return 1
+	}
+
+	return int(v)
+}
+
+func Encode(w io.Writer, img image.Image, format string, quality int) error {
+	if quality == 0 {
+		quality = DefaultQuality
+	}
+
+	switch format {
+	case FormatJPEG:
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	case FormatPNG:
+		return png.Encode(w, img)
+	}
+
+	return fmt.Errorf("transform: unknown format %q", format)
+}
+
+// EncodeWebP goes through the external converter, since Go has no WebP
+// encoder that doesn't need cgo. The image is handed over as a PNG.
+func EncodeWebP(ctx context.Context, command string, img image.Image, quality int) (data []byte, err error) {
+	if quality == 0 {
+		quality = DefaultQuality
+	}
+
+	dir, err := os.MkdirTemp("", "transform-")
+	if err != nil {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src.png")
+	dst := filepath.Join(dir, "dst.webp")
+
+	file, err := os.Create(src)
+	if err != nil {
+		return
+	}
+	err = png.Encode(file, img)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return
+	}
+
+	err = helpers.ConvertImage(ctx, command, src, dst, quality)
+	if err != nil {
+		return
+	}
+
+	data, err = os.ReadFile(dst)
+
+	return
+}
@@ -0,0 +1,121 @@
+package transform
+
+import (
+	"image"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSigner(t *testing.T) {
+	signer := NewSigner("key")
+	params := Params{Width: 320, Fit: FitCover, Format: FormatWebP}
+	expires := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	signature := signer.Sign(7, params, expires)
+
+	tests := []struct {
+		name      string
+		signer    *Signer
+		photoId   int
+		params    Params
+		expires   int64
+		signature string
+		want      bool
+	}{
+		{"valid", signer, 7, params, expires, signature, true},
+		{"other photo", signer, 8, params, expires, signature, false},
+		{"other width", signer, 7, Params{Width: 321, Fit: FitCover, Format: FormatWebP}, expires, signature, false},
+		{"other format", signer, 7, Params{Width: 320, Fit: FitCover, Format: FormatPNG}, expires, signature, false},
+		{"extended expiry", signer, 7, params, expires + 1, signature, false},
+		{"other key", NewSigner("other"), 7, params, expires, signature, false},
+		{"not hex", signer, 7, params, expires, "zz", false},
+		{"empty", signer, 7, params, expires, "", false},
+		{"truncated", signer, 7, params, expires, signature[:32], false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.signer.Verify(tt.photoId, tt.params, tt.expires, tt.signature); got != tt.want {
+				t.Errorf("Verify = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignerURL(t *testing.T) {
+	signer := NewSigner("key")
+	params := Params{Width: 320, Quality: 70}
+	expiresAt := time.Unix(1893456000, 0)
+
+	u, err := url.Parse(signer.URL("/img/7", 7, params, expiresAt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+
+	if u.Path != "/img/7" || query.Get("w") != "320" || query.Get("q") != "70" || query.Get("h") != "" {
+		t.Errorf("unexpected URL %s", u)
+	}
+	expires, err := strconv.ParseInt(query.Get("exp"), 10, 64)
+	if err != nil || expires != expiresAt.Unix() {
+		t.Fatalf("exp = %q, want %d", query.Get("exp"), expiresAt.Unix())
+	}
+	if !signer.Verify(7, params, expires, query.Get("s")) {
+		t.Error("URL signature doesn't verify")
+	}
+}
+
+func TestParamsValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		params Params
+		want   bool
+	}{
+		{"defaults", Params{}, true},
+		{"full", Params{Width: 100, Height: 100, Fit: FitFill, Quality: 100, Format: FormatJPEG}, true},
+		{"at max", Params{Width: 4096, Height: 4096}, true},
+		{"over max", Params{Width: 4097}, false},
+		{"negative", Params{Height: -1}, false},
+		{"quality over 100", Params{Quality: 101}, false},
+		{"unknown fit", Params{Fit: "stretch"}, false},
+		{"unknown format", Params{Format: "gif"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.params.Validate(4096); got != tt.want {
+				t.Errorf("Validate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 400, 200))
+
+	tests := []struct {
+		name   string
+		params Params
+		width  int
+		height int
+	}{
+		{"no size", Params{}, 400, 200},
+		{"width only", Params{Width: 100}, 100, 50},
+		{"height only", Params{Height: 50}, 100, 50},
+		{"contain", Params{Width: 100, Height: 100}, 100, 50},
+		{"cover", Params{Width: 100, Height: 100, Fit: FitCover}, 100, 100},
+		{"fill", Params{Width: 100, Height: 100, Fit: FitFill}, 100, 100},
+		{"never enlarges", Params{Width: 800}, 400, 200},
+		{"fill enlarges", Params{Width: 800, Height: 800, Fit: FitFill}, 800, 800},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bounds := Apply(src, tt.params).Bounds()
+			if bounds.Dx() != tt.width || bounds.Dy() != tt.height {
+				t.Errorf("size %dx%d, want %dx%d", bounds.Dx(), bounds.Dy(), tt.width, tt.height)
+			}
+		})
+	}
+}
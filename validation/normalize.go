@@ -0,0 +1,40 @@
+package validation
+
+import (
+	"reflect"
+	"strings"
+)
+
+func normalize(value reflect.Value) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		rules := value.Type().Field(i).Tag.Get("normalize")
+		if rules == "" || !field.CanSet() {
+			continue
+		}
+
+		switch {
+		case field.Kind() == reflect.String:
+			field.SetString(normalizeString(field.String(), rules))
+		case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+			for j := 0; j < field.Len(); j++ {
+				field.Index(j).SetString(normalizeString(field.Index(j).String(), rules))
+			}
+		}
+	}
+}
+
+func normalizeString(value, rules string) string {
+	for _, rule := range strings.Split(rules, ",") {
+		switch rule {
+		case "trim":
+			value = strings.TrimSpace(value)
+		case "lower":
+			value = strings.ToLower(value)
+		case "squash":
+			value = strings.Join(strings.Fields(value), " ")
+		}
+	}
+
+	return value
+}
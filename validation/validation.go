@@ -0,0 +1,139 @@
+package validation
+
+import (
+	"rakamin/i18n"
+	"rakamin/models"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+const defaultTitleMaxLength = 100
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{3,30}$`)
+
+type Validator struct {
+	validate *validator.Validate
+	userRepo models.UserRepository
+	once     sync.Once
+	err      error
+}
+
+func NewValidator(userRepo models.UserRepository) *Validator {
+	return &Validator{
+		userRepo: userRepo,
+	}
+}
+
+func (v *Validator) Setup() error {
+	v.once.Do(v.setup)
+
+	return v.err
+}
+
+func (v *Validator) ValidateStruct(obj interface{}) error {
+	err := v.Setup()
+	if err != nil || obj == nil {
+		return err
+	}
+
+	value := reflect.ValueOf(obj)
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return nil
+		}
+		if value.Elem().Kind() != reflect.Struct {
+			return v.ValidateStruct(value.Elem().Interface())
+		}
+
+		normalize(value.Elem())
+
+		return v.validate.Struct(obj)
+	case reflect.Struct:
+		return v.validate.Struct(obj)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			item := value.Index(i)
+			if item.CanAddr() {
+				item = item.Addr()
+			}
+
+			err = v.ValidateStruct(item.Interface())
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (v *Validator) Engine() interface{} {
+	v.Setup()
+
+	return v.validate
+}
+
+func (v *Validator) setup() {
+	v.validate = validator.New()
+	v.validate.SetTagName("binding")
+	v.validate.RegisterTagNameFunc(fieldName)
+
+	v.validate.RegisterValidation("username", v.username)
+	v.validate.RegisterValidation("unique_email", v.uniqueEmail)
+	v.validate.RegisterValidation("title", v.title)
+	v.validate.RegisterValidation("locale", v.locale)
+
+	v.err = i18n.RegisterValidator(v.validate)
+}
+
+func (v *Validator) username(fl validator.FieldLevel) bool {
+	return usernamePattern.MatchString(fl.Field().String())
+}
+
+func (v *Validator) uniqueEmail(fl validator.FieldLevel) bool {
+	taken, err := v.userRepo.IsEmailTaken(fl.Field().String())
+
+	return err != nil || !taken
+}
+
+func (v *Validator) title(fl validator.FieldLevel) bool {
+	maxLength, err := strconv.Atoi(fl.Param())
+	if err != nil {
+		maxLength = defaultTitleMaxLength
+	}
+
+	value := fl.Field().String()
+	if strings.IndexFunc(value, unicode.IsControl) >= 0 {
+		return false
+	}
+
+	length := utf8.RuneCountInString(value)
+
+	return length > 0 && length <= maxLength
+}
+
+func (v *Validator) locale(fl validator.FieldLevel) bool {
+	return i18n.IsSupported(fl.Field().String())
+}
+
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "uri"} {
+		name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+var _ binding.StructValidator = (*Validator)(nil)
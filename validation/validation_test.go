@@ -0,0 +1,120 @@
+package validation
+
+import (
+	"errors"
+	"rakamin/i18n"
+	"rakamin/models"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type fakeUserRepo struct {
+	models.UserRepository
+	taken map[string]bool
+	err   error
+}
+
+func (r fakeUserRepo) IsEmailTaken(email string) (bool, error) {
+	return r.taken[email], r.err
+}
+
+type testRequest struct {
+	Username string `json:"username" binding:"omitempty,username"`
+	Email    string `json:"email" binding:"omitempty,unique_email" normalize:"trim,lower"`
+	Title    string `json:"title" binding:"omitempty,title=10" normalize:"trim,squash"`
+	Locale   string `json:"locale" binding:"omitempty,locale"`
+}
+
+func TestCustomRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		repo    fakeUserRepo
+		request testRequest
+		field   string
+	}{
+		{"valid", fakeUserRepo{}, testRequest{Username: "jo.doe_1", Email: "jo@example.com", Title: "Beach", Locale: "id"}, ""},
+		{"username too short", fakeUserRepo{}, testRequest{Username: "jo"}, "username"},
+		{"username with space", fakeUserRepo{}, testRequest{Username: "jo doe"}, "username"},
+		{"email taken", fakeUserRepo{taken: map[string]bool{"jo@example.com": true}}, testRequest{Email: "jo@example.com"}, "email"},
+		{"email taken after normalizing", fakeUserRepo{taken: map[string]bool{"jo@example.com": true}}, testRequest{Email: " JO@example.com "}, "email"},
+		{"email lookup fails open", fakeUserRepo{err: errors.New("db down")}, testRequest{Email: "jo@example.com"}, ""},
+		{"title at max", fakeUserRepo{}, testRequest{Title: "éééééééééé"}, ""},
+		{"title over max", fakeUserRepo{}, testRequest{Title: "12345678901"}, "title"},
+		{"title squashed to fit", fakeUserRepo{}, testRequest{Title: "  a    b  "}, ""},
+		{"title with control character", fakeUserRepo{}, testRequest{Title: "a\x00b"}, "title"},
+		{"unsupported locale", fakeUserRepo{}, testRequest{Locale: "fr"}, "locale"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := tt.request
+			err := NewValidator(tt.repo).ValidateStruct(&request)
+
+			var validationErr validator.ValidationErrors
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+
+				return
+			}
+			if !errors.As(err, &validationErr) || len(validationErr) != 1 || validationErr[0].Field() != tt.field {
+				t.Fatalf("err = %v, want a single error on %s", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	request := testRequest{Email: "  Jo@Example.COM ", Title: "  a \t b\n"}
+
+	err := NewValidator(fakeUserRepo{}).ValidateStruct(&request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request.Email != "jo@example.com" || request.Title != "a b" {
+		t.Errorf("normalized to %q and %q", request.Email, request.Title)
+	}
+}
+
+func TestTranslatedMessages(t *testing.T) {
+	type messageRequest struct {
+		Username string `json:"username" binding:"required,username"`
+		Title    string `json:"title" binding:"title=10"`
+	}
+
+	err := NewValidator(fakeUserRepo{}).ValidateStruct(&messageRequest{Username: "x", Title: "12345678901"})
+
+	var validationErr validator.ValidationErrors
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("err = %v, want validation errors", err)
+	}
+
+	tests := []struct {
+		locale   string
+		username string
+		title    string
+	}{
+		{i18n.English, "username must be 3-30 characters", "title must be a single line of at most 10 characters"},
+		{i18n.Indonesian, "username harus terdiri dari 3-30 karakter", "title harus berupa satu baris dengan maksimal 10 karakter"},
+		{"fr", "username must be 3-30 characters", "title must be a single line of at most 10 characters"},
+		{"", "username must be 3-30 characters", "title must be a single line of at most 10 characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			fields := i18n.FieldErrors(tt.locale, validationErr)
+			if len(fields) != 2 {
+				t.Fatalf("got %d field errors, want 2", len(fields))
+			}
+			if !strings.HasPrefix(fields[0].Message, tt.username) || fields[0].Rule != "username" {
+				t.Errorf("username message %q, want prefix %q", fields[0].Message, tt.username)
+			}
+			if fields[1].Message != tt.title || fields[1].Param != "10" {
+				t.Errorf("title message %q, want %q", fields[1].Message, tt.title)
+			}
+		})
+	}
+}
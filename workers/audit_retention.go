@@ -0,0 +1,58 @@
+package workers
+
+import (
+	"context"
+	"rakamin/logger"
+	"rakamin/models"
+	"sync"
+	"time"
+)
+
+type AuditRetentionWorker struct {
+	auditLogRepo models.AuditLogRepository
+	Retention    time.Duration
+	Interval     time.Duration
+}
+
+func NewAuditRetentionWorker(auditLogRepo models.AuditLogRepository, retentionDays int) *AuditRetentionWorker {
+	return &AuditRetentionWorker{
+		auditLogRepo: auditLogRepo,
+		Retention:    time.Duration(retentionDays) * 24 * time.Hour,
+		Interval:     time.Hour,
+	}
+}
+
+func (w *AuditRetentionWorker) Start(ctx context.Context, wg *sync.WaitGroup) {
+	if w.Retention <= 0 {
+		return
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(w.Interval)
+		defer ticker.Stop()
+
+		for {
+			w.purge()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (w *AuditRetentionWorker) purge() {
+	deleted, err := w.auditLogRepo.DeleteOlderThan(time.Now().Add(-w.Retention))
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("purging audit logs")
+		return
+	}
+	if deleted > 0 {
+		logger.Log.Info().Int64("deleted", deleted).Dur("retention", w.Retention).Msg("purged audit logs")
+	}
+}
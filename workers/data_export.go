@@ -0,0 +1,306 @@
+package workers
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"rakamin/app"
+	"rakamin/apperror"
+	"rakamin/i18n"
+	"rakamin/logger"
+	"rakamin/models"
+	"sync"
+	"time"
+)
+
+const JobTypeDataExport = "export.build"
+
+type DataExportPayload struct {
+	ExportID string `json:"exportId"`
+	UserID   int    `json:"userId"`
+}
+
+type DataExportWorker struct {
+	exportRepo       models.DataExportRepository
+	userRepo         models.UserRepository
+	photoRepo        models.PhotoRepository
+	sessionRepo      models.SessionRepository
+	notificationRepo models.NotificationRepository
+	auditLogRepo     models.AuditLogRepository
+	jobQueue         *JobQueue
+	Dir              string
+	LinkTTL          time.Duration
+}
+
+func NewDataExportWorker(exportRepo models.DataExportRepository, userRepo models.UserRepository, photoRepo models.PhotoRepository, sessionRepo models.SessionRepository, notificationRepo models.NotificationRepository, auditLogRepo models.AuditLogRepository, jobQueue *JobQueue, dir string, linkTTL time.Duration) *DataExportWorker {
+	worker := &DataExportWorker{
+		exportRepo:       exportRepo,
+		userRepo:         userRepo,
+		photoRepo:        photoRepo,
+		sessionRepo:      sessionRepo,
+		notificationRepo: notificationRepo,
+		auditLogRepo:     auditLogRepo,
+		jobQueue:         jobQueue,
+		Dir:              dir,
+		LinkTTL:          linkTTL,
+	}
+	jobQueue.Register(JobTypeDataExport, worker.handle)
+
+	return worker
+}
+
+func (w *DataExportWorker) Enqueue(export models.DataExport) error {
+	_, err := w.jobQueue.Enqueue(JobTypeDataExport, DataExportPayload{
+		ExportID: export.ID,
+		UserID:   export.UserID,
+	})
+
+	return err
+}
+
+func (w *DataExportWorker) Start(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				w.cleanup()
+			}
+		}
+	}()
+}
+
+func (w *DataExportWorker) handle(ctx context.Context, job models.Job) error {
+	var payload DataExportPayload
+
+	err := json.Unmarshal([]byte(job.Payload), &payload)
+	if err != nil {
+		return err
+	}
+
+	return w.process(ctx, models.DataExport{ID: payload.ExportID, UserID: payload.UserID})
+}
+
+// process stores failures as an error code, which is localized when the
+// export is read. The full error only goes to the log.
+func (w *DataExportWorker) process(ctx context.Context, export models.DataExport) error {
+	export.Status = models.ExportStatusProcessing
+	err := w.exportRepo.Update(export)
+	if err != nil {
+		return err
+	}
+
+	path, err := w.build(export.ID, export.UserID)
+	now := time.Now()
+	export.CompletedAt = &now
+	if err != nil {
+		if path != "" {
+			os.Remove(path)
+		}
+		logger.FromContext(ctx).Error().Err(err).Str("exportId", export.ID).Msg("building export")
+		export.Status = models.ExportStatusFailed
+		export.Error = apperror.From(err).Code
+		w.exportRepo.Update(export)
+
+		return err
+	}
+
+	expiresAt := now.Add(w.LinkTTL)
+	export.Status = models.ExportStatusReady
+	export.FilePath = path
+	export.ExpiresAt = &expiresAt
+	err = w.exportRepo.Update(export)
+	if err != nil {
+		return err
+	}
+
+	user, err := w.userRepo.GetById(export.UserID)
+	if err != nil {
+		return err
+	}
+
+	return w.notificationRepo.Insert(models.Notification{
+		UserID:  export.UserID,
+		Title:   i18n.T(user.Locale, "notification.export_ready.title"),
+		Message: i18n.T(user.Locale, "notification.export_ready.message", export.ID, expiresAt.Format(time.RFC1123)),
+	})
+}
+
+func (w *DataExportWorker) build(id string, userId int) (path string, err error) {
+	user, err := w.userRepo.GetById(userId)
+	if err != nil {
+		return
+	}
+	photos, err := w.photoRepo.GetAllByUserIdUnscoped(userId)
+	if err != nil {
+		return
+	}
+	sessions, err := w.sessionRepo.GetAllByUserId(userId)
+	if err != nil {
+		return
+	}
+	notifications, err := w.notificationRepo.GetAllByUserId(userId)
+	if err != nil {
+		return
+	}
+	auditLogs, err := w.auditLogRepo.GetAllByUserId(userId, -1)
+	if err != nil {
+		return
+	}
+
+	err = os.MkdirAll(w.Dir, 0750)
+	if err != nil {
+		return
+	}
+
+	path = filepath.Join(w.Dir, id+".zip")
+	file, err := os.Create(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+	manifest := app.DataExportManifest{
+		GeneratedAt: time.Now(),
+		Profile: app.DataExportProfile{
+			ID:       user.ID,
+			Username: user.Username,
+			Email:    user.Email,
+			Role:     user.Role,
+		},
+		Photos:        []app.DataExportPhoto{},
+		Sessions:      []app.Sessions{},
+		Notifications: []app.Notifications{},
+		AuditLogs:     []app.AuditLogs{},
+		Files:         []app.DataExportFile{},
+	}
+	if user.CreatedAt != nil {
+		manifest.Profile.CreatedAt = *user.CreatedAt
+	}
+
+	for _, photo := range photos {
+		exported := app.DataExportPhoto{
+			ID:          photo.ID,
+			Title:       photo.Title,
+			Caption:     photo.Caption,
+			Tags:        photo.Tags,
+			Album:       photo.Album,
+			Status:      photo.Status,
+			PhotoURL:    photo.PhotoURL,
+			OriginalURL: photo.OriginalURL,
+		}
+		if photo.DeletedAt.Valid {
+			exported.DeletedAt = &photo.DeletedAt.Time
+		}
+
+		source := photo.PhotoURL
+		if photo.OriginalURL != "" {
+			source = photo.OriginalURL
+		}
+
+		name := filepath.Join("photos", filepath.Base(source))
+		size, copyErr := copyIntoArchive(archive, name, source)
+		if copyErr == nil {
+			exported.File = name
+			manifest.Files = append(manifest.Files, app.DataExportFile{Name: name, Size: size})
+		}
+
+		manifest.Photos = append(manifest.Photos, exported)
+	}
+	for _, session := range sessions {
+		manifest.Sessions = append(manifest.Sessions, app.Sessions{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  *session.CreatedAt,
+			LastSeenAt: *session.LastSeenAt,
+		})
+	}
+	for _, notification := range notifications {
+		manifest.Notifications = append(manifest.Notifications, app.Notifications{
+			ID:        notification.ID,
+			Title:     notification.Title,
+			Message:   notification.Message,
+			ReadAt:    notification.ReadAt,
+			CreatedAt: *notification.CreatedAt,
+		})
+	}
+	for _, auditLog := range auditLogs {
+		exported := app.AuditLogs{
+			ID:         auditLog.ID,
+			ActorID:    auditLog.ActorID,
+			Action:     auditLog.Action,
+			TargetType: auditLog.TargetType,
+			TargetID:   auditLog.TargetID,
+			IP:         auditLog.IP,
+			UserAgent:  auditLog.UserAgent,
+			RequestID:  auditLog.RequestID,
+			CreatedAt:  *auditLog.CreatedAt,
+		}
+		if auditLog.Changes != "" {
+			json.Unmarshal([]byte(auditLog.Changes), &exported.Changes)
+		}
+		manifest.AuditLogs = append(manifest.AuditLogs, exported)
+	}
+
+	entry, err := archive.Create("manifest.json")
+	if err != nil {
+		return
+	}
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(manifest)
+	if err != nil {
+		return
+	}
+
+	err = archive.Close()
+
+	return
+}
+
+func (w *DataExportWorker) cleanup() {
+	exports, err := w.exportRepo.GetAllExpiredBefore(time.Now())
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("listing expired exports")
+		return
+	}
+
+	for _, export := range exports {
+		err = os.Remove(export.FilePath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			logger.Log.Error().Err(err).Msg("removing export file")
+			continue
+		}
+		w.exportRepo.DeleteById(export.ID)
+	}
+}
+
+func copyIntoArchive(archive *zip.Writer, name, source string) (size int64, err error) {
+	src, err := os.Open(source)
+	if err != nil {
+		return
+	}
+	defer src.Close()
+
+	dst, err := archive.Create(filepath.ToSlash(name))
+	if err != nil {
+		return
+	}
+
+	size, err = io.Copy(dst, src)
+
+	return
+}
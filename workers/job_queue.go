@@ -0,0 +1,211 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"rakamin/logger"
+	"rakamin/models"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type JobHandler func(ctx context.Context, job models.Job) error
+
+// Running jobs send a heartbeat every HeartbeatInterval. Any replica requeues
+// jobs whose heartbeat is older than StaleAfter, so a crashed worker's jobs
+// are picked up again without waiting for a restart.
+type JobQueue struct {
+	jobRepo           models.JobRepository
+	handlers          map[string]JobHandler
+	Concurrency       int
+	MaxAttempts       int
+	Backoff           time.Duration
+	PollInterval      time.Duration
+	HeartbeatInterval time.Duration
+	StaleAfter        time.Duration
+	name              string
+	wg                sync.WaitGroup
+}
+
+func NewJobQueue(jobRepo models.JobRepository, concurrency, maxAttempts int, backoff, pollInterval time.Duration) *JobQueue {
+	hostname, _ := os.Hostname()
+
+	return &JobQueue{
+		jobRepo:           jobRepo,
+		handlers:          map[string]JobHandler{},
+		Concurrency:       concurrency,
+		MaxAttempts:       maxAttempts,
+		Backoff:           backoff,
+		PollInterval:      pollInterval,
+		HeartbeatInterval: 30 * time.Second,
+		StaleAfter:        2 * time.Minute,
+		name:              fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+	}
+}
+
+func (q *JobQueue) Register(jobType string, handler JobHandler) {
+	q.handlers[jobType] = handler
+}
+
+func (q *JobQueue) Enqueue(jobType string, payload interface{}) (id int, err error) {
+	return q.EnqueueAt(jobType, payload, time.Now())
+}
+
+func (q *JobQueue) EnqueueAt(jobType string, payload interface{}, runAt time.Time) (id int, err error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+
+	id, err = q.jobRepo.Insert(models.Job{
+		Type:        jobType,
+		Payload:     string(encoded),
+		Status:      models.JobStatusQueued,
+		MaxAttempts: q.MaxAttempts,
+		RunAt:       runAt,
+	})
+
+	return
+}
+
+func (q *JobQueue) Start(ctx context.Context) {
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+
+		ticker := time.NewTicker(q.HeartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			q.releaseStale()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	for i := 0; i < q.Concurrency; i++ {
+		q.wg.Add(1)
+		go q.work(ctx, fmt.Sprintf("%s-%d", q.name, i))
+	}
+}
+
+func (q *JobQueue) releaseStale() {
+	released, err := q.jobRepo.ReleaseStale(time.Now().Add(-q.StaleAfter))
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("releasing stale jobs")
+	}
+	if released > 0 {
+		logger.Log.Info().Int64("released", released).Msg("released stale jobs")
+	}
+}
+
+func (q *JobQueue) Wait() {
+	q.wg.Wait()
+}
+
+func (q *JobQueue) work(ctx context.Context, worker string) {
+	defer q.wg.Done()
+
+	for {
+		job, err := q.jobRepo.ClaimNext(worker, time.Now())
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				logger.Log.Error().Err(err).Msg("claiming job")
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(q.PollInterval):
+			}
+
+			continue
+		}
+
+		q.run(ctx, worker, job)
+
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (q *JobQueue) run(ctx context.Context, worker string, job models.Job) {
+	handler, ok := q.handlers[job.Type]
+	if !ok {
+		q.jobRepo.MarkDead(job.ID, fmt.Sprintf("no handler registered for %s", job.Type))
+		return
+	}
+
+	stop := q.heartbeat(worker, job)
+	err := q.invoke(ctx, handler, job)
+	stop()
+	if err == nil {
+		q.jobRepo.MarkSucceeded(job.ID)
+		return
+	}
+
+	logger.Log.Warn().Err(err).Int("jobId", job.ID).Str("jobType", job.Type).Int("attempt", job.Attempts).Msg("job attempt failed")
+
+	if job.Attempts >= job.MaxAttempts {
+		q.jobRepo.MarkDead(job.ID, err.Error())
+		return
+	}
+
+	q.jobRepo.MarkRetry(job.ID, err.Error(), time.Now().Add(q.retryDelay(job.Attempts)))
+}
+
+// heartbeat keeps the job's claim fresh until stop is called.
+func (q *JobQueue) heartbeat(worker string, job models.Job) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(q.HeartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				err := q.jobRepo.Heartbeat(job.ID, worker, now)
+				if err != nil {
+					logger.Log.Warn().Err(err).Int("jobId", job.ID).Str("jobType", job.Type).Msg("job heartbeat failed")
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// retryDelay doubles Backoff with every failed attempt.
+func (q *JobQueue) retryDelay(attempts int) time.Duration {
+	return time.Duration(float64(q.Backoff) * math.Pow(2, float64(attempts-1)))
+}
+
+func (q *JobQueue) invoke(ctx context.Context, handler JobHandler, job models.Job) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	return handler(ctx, job)
+}
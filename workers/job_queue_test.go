@@ -0,0 +1,208 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"rakamin/models"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type fakeJobRepository struct {
+	models.JobRepository
+	mu         sync.Mutex
+	status     string
+	lastError  string
+	runAt      time.Time
+	heartbeats []string
+	releasedAt []time.Time
+}
+
+func (r *fakeJobRepository) Heartbeat(id int, worker string, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.heartbeats = append(r.heartbeats, worker)
+	return nil
+}
+
+func (r *fakeJobRepository) ReleaseStale(before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.releasedAt = append(r.releasedAt, before)
+	return 0, nil
+}
+
+func (r *fakeJobRepository) ClaimNext(worker string, now time.Time) (models.Job, error) {
+	return models.Job{}, gorm.ErrRecordNotFound
+}
+
+func (r *fakeJobRepository) MarkSucceeded(id int) error {
+	r.status = models.JobStatusSucceeded
+	return nil
+}
+
+func (r *fakeJobRepository) MarkRetry(id int, lastError string, runAt time.Time) error {
+	r.status, r.lastError, r.runAt = models.JobStatusQueued, lastError, runAt
+	return nil
+}
+
+func (r *fakeJobRepository) MarkDead(id int, lastError string) error {
+	r.status, r.lastError = models.JobStatusDead, lastError
+	return nil
+}
+
+func TestJobQueueRetryDelay(t *testing.T) {
+	q := NewJobQueue(nil, 1, 5, time.Second, time.Second)
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := q.retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestJobQueueRun(t *testing.T) {
+	failed := errors.New("failed")
+
+	tests := []struct {
+		name      string
+		jobType   string
+		attempts  int
+		handler   JobHandler
+		status    string
+		lastError string
+		delay     time.Duration
+	}{
+		{
+			name:    "succeeds",
+			jobType: "test",
+			handler: func(ctx context.Context, job models.Job) error { return nil },
+			status:  models.JobStatusSucceeded,
+		},
+		{
+			name:      "first failure retries after backoff",
+			jobType:   "test",
+			attempts:  1,
+			handler:   func(ctx context.Context, job models.Job) error { return failed },
+			status:    models.JobStatusQueued,
+			lastError: "failed",
+			delay:     time.Minute,
+		},
+		{
+			name:      "later failure backs off further",
+			jobType:   "test",
+			attempts:  2,
+			handler:   func(ctx context.Context, job models.Job) error { return failed },
+			status:    models.JobStatusQueued,
+			lastError: "failed",
+			delay:     2 * time.Minute,
+		},
+		{
+			name:      "last attempt is dead",
+			jobType:   "test",
+			attempts:  3,
+			handler:   func(ctx context.Context, job models.Job) error { return failed },
+			status:    models.JobStatusDead,
+			lastError: "failed",
+		},
+		{
+			name:      "panic counts as failure",
+			jobType:   "test",
+			attempts:  1,
+			handler:   func(ctx context.Context, job models.Job) error { panic("boom") },
+			status:    models.JobStatusQueued,
+			lastError: "panic: boom",
+			delay:     time.Minute,
+		},
+		{
+			name:      "unknown type is dead",
+			jobType:   "other",
+			attempts:  1,
+			status:    models.JobStatusDead,
+			lastError: "no handler registered for other",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeJobRepository{}
+			q := NewJobQueue(repo, 1, 3, time.Minute, time.Second)
+			if tt.handler != nil {
+				q.Register("test", tt.handler)
+			}
+
+			before := time.Now()
+			q.run(context.Background(), "worker", models.Job{ID: 1, Type: tt.jobType, Attempts: tt.attempts, MaxAttempts: 3})
+
+			if repo.status != tt.status || repo.lastError != tt.lastError {
+				t.Fatalf("status %q, error %q, want %q, %q", repo.status, repo.lastError, tt.status, tt.lastError)
+			}
+			if tt.delay > 0 && (repo.runAt.Before(before.Add(tt.delay)) || repo.runAt.After(time.Now().Add(tt.delay))) {
+				t.Errorf("retry at %s, want %s after %s", repo.runAt, tt.delay, before)
+			}
+		})
+	}
+}
+
+func TestJobQueueHeartbeat(t *testing.T) {
+	repo := &fakeJobRepository{}
+	q := NewJobQueue(repo, 1, 3, time.Minute, time.Second)
+	q.HeartbeatInterval = 5 * time.Millisecond
+	q.Register("test", func(ctx context.Context, job models.Job) error {
+		time.Sleep(30 * time.Millisecond)
+		return nil
+	})
+
+	q.run(context.Background(), "worker-1", models.Job{ID: 1, Type: "test", Attempts: 1, MaxAttempts: 3})
+
+	repo.mu.Lock()
+	heartbeats := len(repo.heartbeats)
+	repo.mu.Unlock()
+	if heartbeats == 0 || repo.heartbeats[0] != "worker-1" {
+		t.Fatalf("heartbeats %v, want some from worker-1", repo.heartbeats)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if len(repo.heartbeats) != heartbeats {
+		t.Errorf("heartbeats kept going after the job finished")
+	}
+}
+
+func TestJobQueueReleasesStaleJobsPeriodically(t *testing.T) {
+	repo := &fakeJobRepository{}
+	q := NewJobQueue(repo, 1, 3, time.Minute, time.Millisecond)
+	q.HeartbeatInterval = 5 * time.Millisecond
+	q.StaleAfter = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q.Start(ctx)
+	time.Sleep(30 * time.Millisecond)
+	cancel()
+	q.Wait()
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if len(repo.releasedAt) < 2 {
+		t.Fatalf("released %d times, want a sweep per interval", len(repo.releasedAt))
+	}
+	if cutoff := time.Since(repo.releasedAt[0]); cutoff < time.Hour {
+		t.Errorf("released jobs with a heartbeat %s old, want at least %s", cutoff, q.StaleAfter)
+	}
+}
@@ -0,0 +1,401 @@
+package workers
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"rakamin/app"
+	"rakamin/apperror"
+	"rakamin/events"
+	"rakamin/helpers"
+	"rakamin/i18n"
+	"rakamin/logger"
+	"rakamin/models"
+	"rakamin/quota"
+	"rakamin/validation"
+	"strings"
+	"time"
+)
+
+const JobTypePhotoImport = "photo.import"
+
+type PhotoImportPayload struct {
+	ImportID string `json:"importId"`
+	UserID   int    `json:"userId"`
+}
+
+const (
+	importResultImported = "imported"
+	importResultSkipped  = "skipped"
+	importResultRejected = "rejected"
+)
+
+type PhotoImportWorker struct {
+	importRepo       models.PhotoImportRepository
+	userRepo         models.UserRepository
+	photoRepo        models.PhotoRepository
+	notificationRepo models.NotificationRepository
+	photoProcessor   *PhotoProcessor
+	quotaChecker     *quota.Checker
+	imageStore       *helpers.ImageStore
+	jobQueue         *JobQueue
+	publisher        events.Publisher
+	validator        *validation.Validator
+	MaxFileSize      int64
+	MaxEntries       int
+}
+
+func NewPhotoImportWorker(importRepo models.PhotoImportRepository, userRepo models.UserRepository, photoRepo models.PhotoRepository, notificationRepo models.NotificationRepository, photoProcessor *PhotoProcessor, quotaChecker *quota.Checker, imageStore *helpers.ImageStore, jobQueue *JobQueue, publisher events.Publisher, validator *validation.Validator, maxFileSize int64, maxEntries int) *PhotoImportWorker {
+	worker := &PhotoImportWorker{
+		importRepo:       importRepo,
+		userRepo:         userRepo,
+		photoRepo:        photoRepo,
+		notificationRepo: notificationRepo,
+		photoProcessor:   photoProcessor,
+		quotaChecker:     quotaChecker,
+		imageStore:       imageStore,
+		jobQueue:         jobQueue,
+		publisher:        publisher,
+		validator:        validator,
+		MaxFileSize:      maxFileSize,
+		MaxEntries:       maxEntries,
+	}
+	jobQueue.Register(JobTypePhotoImport, worker.handle)
+
+	return worker
+}
+
+func (w *PhotoImportWorker) Enqueue(photoImport models.PhotoImport) error {
+	_, err := w.jobQueue.Enqueue(JobTypePhotoImport, PhotoImportPayload{
+		ImportID: photoImport.ID,
+		UserID:   photoImport.UserID,
+	})
+
+	return err
+}
+
+func (w *PhotoImportWorker) handle(ctx context.Context, job models.Job) error {
+	var payload PhotoImportPayload
+
+	err := json.Unmarshal([]byte(job.Payload), &payload)
+	if err != nil {
+		return err
+	}
+
+	photoImport, err := w.importRepo.GetById(payload.UserID, payload.ImportID)
+	if err != nil {
+		return err
+	}
+
+	switch photoImport.Status {
+	case models.ImportStatusPending:
+		return w.process(ctx, photoImport)
+	case models.ImportStatusProcessing:
+		// A previous attempt died half way. Photos it imported are already
+		// saved, so running the archive again would duplicate them.
+		os.Remove(photoImport.ArchivePath)
+
+		return w.finish(ctx, photoImport, nil, apperror.ErrImportInterrupted)
+	}
+
+	return nil
+}
+
+func (w *PhotoImportWorker) process(ctx context.Context, photoImport models.PhotoImport) error {
+	defer os.Remove(photoImport.ArchivePath)
+
+	photoImport.Status = models.ImportStatusProcessing
+	err := w.importRepo.Update(photoImport)
+	if err != nil {
+		return err
+	}
+
+	results, err := w.importArchive(ctx, &photoImport)
+	if err != nil && apperror.From(err).Status >= http.StatusInternalServerError {
+		return err
+	}
+
+	return w.finish(ctx, photoImport, results, err)
+}
+
+// finish stores the outcome and notifies the owner. Import errors are saved
+// as codes and localized when the import is read.
+func (w *PhotoImportWorker) finish(ctx context.Context, photoImport models.PhotoImport, results []app.PhotoImportResult, importErr error) error {
+	now := time.Now()
+	photoImport.CompletedAt = &now
+	photoImport.Status = models.ImportStatusCompleted
+	if importErr != nil {
+		photoImport.Status = models.ImportStatusFailed
+		photoImport.Error = apperror.From(importErr).Code
+	}
+	if results != nil {
+		encoded, err := json.Marshal(results)
+		if err != nil {
+			return err
+		}
+		photoImport.Results = string(encoded)
+	}
+
+	err := w.importRepo.Update(photoImport)
+	if err != nil {
+		return err
+	}
+
+	user, err := w.userRepo.GetById(photoImport.UserID)
+	if err != nil {
+		return err
+	}
+
+	notification := models.Notification{
+		UserID:  photoImport.UserID,
+		Title:   i18n.T(user.Locale, "notification.import_finished.title"),
+		Message: i18n.T(user.Locale, "notification.import_finished.message", photoImport.Imported, photoImport.Skipped, photoImport.Rejected),
+	}
+	if importErr != nil {
+		notification.Title = i18n.T(user.Locale, "notification.import_failed.title")
+		notification.Message = i18n.T(user.Locale, "notification.import_failed.message", i18n.Error(user.Locale, apperror.From(importErr)), photoImport.Imported)
+	}
+
+	return w.notificationRepo.Insert(notification)
+}
+
+func (w *PhotoImportWorker) importArchive(ctx context.Context, photoImport *models.PhotoImport) (results []app.PhotoImportResult, err error) {
+	archive, err := zip.OpenReader(photoImport.ArchivePath)
+	if err != nil {
+		err = apperror.ErrInvalidArchive.Wrap(err)
+		return
+	}
+	defer archive.Close()
+
+	var (
+		entries  []*zip.File
+		metadata map[string]app.PhotoImportMetadata
+	)
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		switch strings.ToLower(path.Base(file.Name)) {
+		case "metadata.csv":
+			metadata, err = readCSVMetadata(file)
+		case "metadata.json":
+			metadata, err = readJSONMetadata(file)
+		default:
+			entries = append(entries, file)
+			continue
+		}
+		if err != nil {
+			err = apperror.ErrInvalidSidecar.Wrap(err)
+			return
+		}
+	}
+
+	if w.MaxEntries > 0 && len(entries) > w.MaxEntries {
+		err = apperror.ErrTooManyFiles.Wrap(fmt.Errorf("archive contains %d files, the limit is %d", len(entries), w.MaxEntries))
+		return
+	}
+
+	photoImport.Total = len(entries)
+	err = w.importRepo.Update(*photoImport)
+	if err != nil {
+		return
+	}
+
+	for _, file := range entries {
+		result := w.importEntry(ctx, photoImport.UserID, file, metadata)
+		results = append(results, result)
+
+		switch result.Status {
+		case importResultImported:
+			photoImport.Imported++
+		case importResultSkipped:
+			photoImport.Skipped++
+		default:
+			photoImport.Rejected++
+		}
+		photoImport.Processed++
+
+		err = w.importRepo.Update(*photoImport)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (w *PhotoImportWorker) importEntry(ctx context.Context, userId int, file *zip.File, metadata map[string]app.PhotoImportMetadata) (result app.PhotoImportResult) {
+	result.File = file.Name
+	base := path.Base(file.Name)
+
+	reject := func(err error) app.PhotoImportResult {
+		appErr := apperror.From(err)
+		if appErr.Status >= http.StatusInternalServerError {
+			logger.FromContext(ctx).Error().Err(err).Str("file", file.Name).Msg("importing photo")
+		}
+		result.Status = importResultRejected
+		result.Code = appErr.Code
+
+		return result
+	}
+
+	if strings.HasPrefix(base, ".") || strings.HasPrefix(file.Name, "__MACOSX/") {
+		result.Status = importResultSkipped
+		result.Code = apperror.ErrSystemFile.Code
+		return
+	}
+
+	if w.MaxFileSize > 0 && file.UncompressedSize64 > uint64(w.MaxFileSize) {
+		return reject(apperror.ErrFileTooLarge)
+	}
+
+	meta := metadata[base]
+	if meta.Title == "" {
+		meta.Title = strings.TrimSuffix(base, path.Ext(base))
+	}
+	err := w.validator.ValidateStruct(&meta)
+	if err != nil {
+		return reject(apperror.ErrInvalidMetadata.Wrap(err))
+	}
+
+	err = w.quotaChecker.Check(ctx, userId, int64(file.UncompressedSize64), 0, true)
+	if err != nil {
+		return reject(err)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return reject(apperror.ErrFileUnreadable.Wrap(err))
+	}
+	defer src.Close()
+
+	stored, err := w.imageStore.Save(ctx, src, w.MaxFileSize)
+	if err != nil {
+		return reject(err)
+	}
+
+	photo := models.Photo{
+		Title:    meta.Title,
+		Caption:  meta.Caption,
+		Tags:     meta.Tags,
+		Album:    meta.Album,
+		PhotoURL: stored.Path,
+		Status:   models.PhotoStatusPending,
+		Hash:     stored.Hash,
+		Size:     stored.Size,
+		Width:    stored.Width,
+		Height:   stored.Height,
+		UserID:   userId,
+	}
+
+	result.PhotoID, err = w.photoRepo.WithContext(ctx).Insert(photo)
+	if err != nil {
+		os.Remove(stored.Path)
+		return reject(apperror.ErrFileNotSaved.Wrap(err))
+	}
+
+	err = w.photoProcessor.Enqueue(result.PhotoID, userId)
+	if err != nil {
+		// Nothing would ever process the photo, so don't keep it around as
+		// pending.
+		purgeErr := w.photoRepo.WithContext(ctx).PurgeById(result.PhotoID)
+		if purgeErr == nil {
+			os.Remove(stored.Path)
+		} else {
+			logger.FromContext(ctx).Error().Err(purgeErr).Int("photoId", result.PhotoID).Msg("removing unqueued photo")
+		}
+		result.PhotoID = 0
+
+		return reject(apperror.ErrFileNotSaved.Wrap(err))
+	}
+	result.Status = importResultImported
+
+	w.publisher.Publish(events.New(events.PhotoCreated, userId, app.Photos{
+		ID:       result.PhotoID,
+		Title:    photo.Title,
+		Caption:  photo.Caption,
+		PhotoURL: photo.PhotoURL,
+		Tags:     photo.Tags,
+		Album:    photo.Album,
+		Status:   photo.Status,
+		UserID:   userId,
+	}))
+
+	return
+}
+
+func readCSVMetadata(file *zip.File) (metadata map[string]app.PhotoImportMetadata, err error) {
+	src, err := file.Open()
+	if err != nil {
+		return
+	}
+	defer src.Close()
+
+	rows, err := csv.NewReader(src).ReadAll()
+	if err != nil {
+		return
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	columns := map[string]int{}
+	for index, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = index
+	}
+	if _, ok := columns["file"]; !ok {
+		err = errors.New("missing file column")
+		return
+	}
+
+	column := func(row []string, name string) string {
+		index, ok := columns[name]
+		if !ok || index >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[index])
+	}
+
+	metadata = map[string]app.PhotoImportMetadata{}
+	for _, row := range rows[1:] {
+		meta := app.PhotoImportMetadata{
+			File:    column(row, "file"),
+			Title:   column(row, "title"),
+			Caption: column(row, "caption"),
+			Tags:    column(row, "tags"),
+			Album:   column(row, "album"),
+		}
+		metadata[path.Base(meta.File)] = meta
+	}
+
+	return
+}
+
+func readJSONMetadata(file *zip.File) (metadata map[string]app.PhotoImportMetadata, err error) {
+	var entries []app.PhotoImportMetadata
+
+	src, err := file.Open()
+	if err != nil {
+		return
+	}
+	defer src.Close()
+
+	err = json.NewDecoder(src).Decode(&entries)
+	if err != nil {
+		return
+	}
+
+	metadata = map[string]app.PhotoImportMetadata{}
+	for _, meta := range entries {
+		metadata[path.Base(meta.File)] = meta
+	}
+
+	return
+}
@@ -0,0 +1,231 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"rakamin/app"
+	"rakamin/events"
+	"rakamin/helpers"
+	"rakamin/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const JobTypePhotoProcess = "photo.process"
+
+var (
+	errUndecodableImage = errors.New("file is not a decodable image")
+	errNoConverter      = errors.New("no heic converter configured")
+)
+
+type PhotoProcessPayload struct {
+	PhotoID int `json:"photoId"`
+	UserID  int `json:"userId"`
+}
+
+type PhotoProcessor struct {
+	photoRepo     models.PhotoRepository
+	jobQueue      *JobQueue
+	publisher     events.Publisher
+	heicConverter string
+	webpConverter string
+	webpQuality   int
+}
+
+func NewPhotoProcessor(photoRepo models.PhotoRepository, jobQueue *JobQueue, publisher events.Publisher, heicConverter, webpConverter string, webpQuality int) *PhotoProcessor {
+	processor := &PhotoProcessor{
+		photoRepo:     photoRepo,
+		jobQueue:      jobQueue,
+		publisher:     publisher,
+		heicConverter: heicConverter,
+		webpConverter: webpConverter,
+		webpQuality:   webpQuality,
+	}
+	jobQueue.Register(JobTypePhotoProcess, processor.handle)
+
+	return processor
+}
+
+func (p *PhotoProcessor) Enqueue(photoId, userId int) error {
+	_, err := p.jobQueue.Enqueue(JobTypePhotoProcess, PhotoProcessPayload{
+		PhotoID: photoId,
+		UserID:  userId,
+	})
+
+	return err
+}
+
+func (p *PhotoProcessor) handle(ctx context.Context, job models.Job) error {
+	var payload PhotoProcessPayload
+
+	err := json.Unmarshal([]byte(job.Payload), &payload)
+	if err != nil {
+		return err
+	}
+
+	photo, err := p.photoRepo.GetById(payload.UserID, payload.PhotoID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	err = p.process(ctx, &photo)
+	if errors.Is(err, errUndecodableImage) {
+		return p.fail(photo)
+	}
+	if err != nil {
+		if job.Attempts >= job.MaxAttempts {
+			p.fail(photo)
+		}
+		return err
+	}
+
+	photo.Status = models.PhotoStatusReady
+	err = p.photoRepo.UpdateProcessedById(photo)
+	if err != nil {
+		return err
+	}
+
+	p.publish(photo)
+
+	return nil
+}
+
+func (p *PhotoProcessor) fail(photo models.Photo) error {
+	photo.Status = models.PhotoStatusFailed
+	err := p.photoRepo.UpdatePhotoById(models.Photo{ID: photo.ID, UserID: photo.UserID, Status: photo.Status})
+	if err != nil {
+		return err
+	}
+
+	p.publish(photo)
+
+	return nil
+}
+
+func (p *PhotoProcessor) publish(photo models.Photo) {
+	p.publisher.Publish(events.New(events.PhotoProcessed, photo.UserID, app.Photos{
+		ID:          photo.ID,
+		Title:       photo.Title,
+		Caption:     photo.Caption,
+		PhotoURL:    photo.PhotoURL,
+		OriginalURL: photo.OriginalURL,
+		Tags:        photo.Tags,
+		Album:       photo.Album,
+		Status:      photo.Status,
+		UserID:      photo.UserID,
+	}))
+}
+
+// process derives the display and WebP variants of the photo. A photo whose
+// PhotoURL already points into the variant dir was converted by an earlier
+// attempt, so it starts again from the original.
+func (p *PhotoProcessor) process(ctx context.Context, photo *models.Photo) error {
+	source := photo.PhotoURL
+	if photo.OriginalURL != "" && strings.HasPrefix(photo.PhotoURL, helpers.VariantDir) {
+		source = photo.OriginalURL
+	}
+
+	filetype, err := sniffImageFile(source)
+	if err != nil {
+		return err
+	}
+
+	photo.PhotoURL = source
+	photo.OriginalURL = ""
+	photo.VariantBytes = 0
+
+	if !helpers.IsWebDisplayable(filetype) {
+		display, err := helpers.VariantPath(source, helpers.ImageTypeJPEG)
+		if err != nil {
+			return err
+		}
+
+		size, err := p.convert(ctx, source, display)
+		if err != nil {
+			return err
+		}
+
+		photo.OriginalURL = source
+		photo.PhotoURL = display
+		photo.VariantBytes += size
+		filetype = helpers.ImageTypeJPEG
+	}
+
+	if p.webpConverter != "" && (filetype == helpers.ImageTypeJPEG || filetype == helpers.ImageTypePNG) {
+		size, err := p.writeWebP(ctx, photo.PhotoURL)
+		if err != nil {
+			return err
+		}
+
+		photo.VariantBytes += size
+	}
+
+	return nil
+}
+
+func (p *PhotoProcessor) convert(ctx context.Context, source, target string) (size int64, err error) {
+	if strings.TrimSpace(p.heicConverter) == "" {
+		err = errNoConverter
+		return
+	}
+
+	return runConverter(ctx, p.heicConverter, source, target, 0)
+}
+
+func (p *PhotoProcessor) writeWebP(ctx context.Context, source string) (size int64, err error) {
+	target, err := helpers.VariantPath(source, helpers.ImageTypeWebP)
+	if err != nil {
+		return
+	}
+
+	return runConverter(ctx, p.webpConverter, source, target, p.webpQuality)
+}
+
+func runConverter(ctx context.Context, command, source, target string, quality int) (size int64, err error) {
+	err = os.MkdirAll(helpers.VariantDir, 0750)
+	if err != nil {
+		return
+	}
+
+	err = helpers.ConvertImage(ctx, command, source, target, quality)
+	if err != nil {
+		return
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		return
+	}
+	size = info.Size()
+
+	return
+}
+
+func sniffImageFile(path string) (filetype string, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	header := make([]byte, 512)
+	n, err := io.ReadFull(file, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return
+	}
+	err = nil
+
+	filetype = helpers.SniffImageType(header[:n])
+	if !helpers.IsSupportedImageType(filetype) {
+		err = errUndecodableImage
+	}
+
+	return
+}
@@ -0,0 +1,93 @@
+package workers
+
+import (
+	"context"
+	"rakamin/helpers"
+	"rakamin/logger"
+	"rakamin/models"
+	"sync"
+	"time"
+)
+
+type TrashPurgeWorker struct {
+	userRepo    models.UserRepository
+	photoRepo   models.PhotoRepository
+	GracePeriod time.Duration
+	Interval    time.Duration
+}
+
+func NewTrashPurgeWorker(userRepo models.UserRepository, photoRepo models.PhotoRepository, gracePeriod time.Duration) *TrashPurgeWorker {
+	return &TrashPurgeWorker{
+		userRepo:    userRepo,
+		photoRepo:   photoRepo,
+		GracePeriod: gracePeriod,
+		Interval:    time.Hour,
+	}
+}
+
+func (w *TrashPurgeWorker) Start(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(w.Interval)
+		defer ticker.Stop()
+
+		for {
+			w.purge()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (w *TrashPurgeWorker) purge() {
+	before := time.Now().Add(-w.GracePeriod)
+
+	photos, err := w.photoRepo.GetAllDeletedBefore(before)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("listing trashed photos")
+		return
+	}
+	for _, photo := range photos {
+		w.purgePhoto(photo)
+	}
+
+	users, err := w.userRepo.GetAllDeletedBefore(before)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("listing deleted users")
+		return
+	}
+	for _, user := range users {
+		photos, err := w.photoRepo.GetAllByUserIdUnscoped(user.ID)
+		if err != nil {
+			logger.Log.Error().Err(err).Msg("listing photos of deleted user")
+			continue
+		}
+		for _, photo := range photos {
+			w.purgePhoto(photo)
+		}
+
+		err = w.userRepo.PurgeById(user.ID)
+		if err != nil {
+			logger.Log.Error().Err(err).Msg("purging user")
+		}
+	}
+}
+
+func (w *TrashPurgeWorker) purgePhoto(photo models.Photo) {
+	err := helpers.RemoveImageFiles(photo.PhotoURL, photo.OriginalURL)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("removing photo file")
+		return
+	}
+
+	err = w.photoRepo.PurgeById(photo.ID)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("purging photo")
+	}
+}
@@ -0,0 +1,196 @@
+package workers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"rakamin/events"
+	"rakamin/logger"
+	"rakamin/models"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	JobTypeWebhookDelivery = "webhook.deliver"
+	webhookResponseLimit   = 4096
+)
+
+var errPrivateWebhookTarget = errors.New("webhook target resolves to a private address")
+
+type WebhookDeliveryPayload struct {
+	WebhookID int    `json:"webhookId"`
+	EventID   string `json:"eventId"`
+	EventType string `json:"eventType"`
+	Body      string `json:"body"`
+}
+
+type WebhookDispatcher struct {
+	webhookRepo models.WebhookRepository
+	jobQueue    *JobQueue
+	client      *http.Client
+}
+
+func NewWebhookDispatcher(webhookRepo models.WebhookRepository, jobQueue *JobQueue, timeout time.Duration, allowPrivateTargets bool) *WebhookDispatcher {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivateTargets {
+		dialer.Control = rejectPrivateTargets
+	}
+
+	dispatcher := &WebhookDispatcher{
+		webhookRepo: webhookRepo,
+		jobQueue:    jobQueue,
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+	jobQueue.Register(JobTypeWebhookDelivery, dispatcher.handle)
+
+	return dispatcher
+}
+
+func (d *WebhookDispatcher) Publish(event events.Event) {
+	webhooks, err := d.webhookRepo.GetAllActiveForUser(event.UserID)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("listing webhooks")
+		return
+	}
+
+	for _, webhook := range webhooks {
+		if !webhook.Subscribed(event.Type) {
+			continue
+		}
+
+		err = d.Enqueue(webhook, event)
+		if err != nil {
+			logger.Log.Error().Err(err).Msg("enqueueing webhook delivery")
+		}
+	}
+}
+
+func (d *WebhookDispatcher) Enqueue(webhook models.Webhook, event events.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return d.Redeliver(models.WebhookDelivery{
+		WebhookID: webhook.ID,
+		EventID:   event.ID,
+		EventType: event.Type,
+		Payload:   string(body),
+	})
+}
+
+func (d *WebhookDispatcher) Redeliver(delivery models.WebhookDelivery) error {
+	_, err := d.jobQueue.Enqueue(JobTypeWebhookDelivery, WebhookDeliveryPayload{
+		WebhookID: delivery.WebhookID,
+		EventID:   delivery.EventID,
+		EventType: delivery.EventType,
+		Body:      delivery.Payload,
+	})
+
+	return err
+}
+
+func (d *WebhookDispatcher) handle(ctx context.Context, job models.Job) error {
+	var payload WebhookDeliveryPayload
+
+	err := json.Unmarshal([]byte(job.Payload), &payload)
+	if err != nil {
+		return err
+	}
+
+	webhook, err := d.webhookRepo.GetById(payload.WebhookID)
+	if err != nil || !webhook.Active {
+		return nil
+	}
+
+	delivery := models.WebhookDelivery{
+		WebhookID: webhook.ID,
+		EventID:   payload.EventID,
+		EventType: payload.EventType,
+		Payload:   payload.Body,
+		Attempt:   job.Attempts,
+	}
+
+	err = d.send(ctx, webhook, &delivery)
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+	d.webhookRepo.InsertDelivery(delivery)
+
+	return err
+}
+
+func (d *WebhookDispatcher) send(ctx context.Context, webhook models.Webhook, delivery *models.WebhookDelivery) error {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "rakamin-webhooks/1.0")
+	req.Header.Set("X-Webhook-Id", strconv.Itoa(webhook.ID))
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Delivery", delivery.EventID)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+SignWebhook(webhook.Secret, timestamp, []byte(delivery.Payload)))
+
+	start := time.Now()
+	res, err := d.client.Do(req)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, webhookResponseLimit))
+	delivery.StatusCode = res.StatusCode
+	delivery.ResponseBody = string(body)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", res.StatusCode)
+	}
+	delivery.Success = true
+
+	return nil
+}
+
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func rejectPrivateTargets(network, address string, conn syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+		return errPrivateWebhookTarget
+	}
+
+	return nil
+}